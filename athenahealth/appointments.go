@@ -192,6 +192,63 @@ func (h *HTTPClient) ListBookedAppointments(ctx context.Context, opts *ListBooke
 	}, nil
 }
 
+// BookedAppointmentIterator pages through a list of appointments. Call Next to advance and Value to
+// get the current appointment.
+type BookedAppointmentIterator struct {
+	*iterator
+}
+
+// Value returns the current appointment.
+func (i *BookedAppointmentIterator) Value() *BookedAppointment {
+	v, _ := i.current.(*BookedAppointment)
+	return v
+}
+
+// NewBookedAppointmentIterator returns an iterator over booked appointments, fetching pages from
+// ListBookedAppointments as needed.
+func NewBookedAppointmentIterator(client Client, opts *ListBookedAppointmentsOptions, iterOpts *IteratorOptions) *BookedAppointmentIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListBookedAppointmentsOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListBookedAppointments(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.BookedAppointments))
+		for i, item := range res.BookedAppointments {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+
+	return &BookedAppointmentIterator{newIterator(fetch, start, iterOpts)}
+}
+
+// ListAllBookedAppointments returns booked appointments, fetching pages until there are no more,
+// iterOpts.MaxItems is reached or ctx is done. On error, the results fetched so far are returned
+// with it.
+func (h *HTTPClient) ListAllBookedAppointments(ctx context.Context, opts *ListBookedAppointmentsOptions, iterOpts *IteratorOptions) ([]*BookedAppointment, error) {
+	it := NewBookedAppointmentIterator(h, opts, iterOpts)
+
+	var out []*BookedAppointment
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
+
 type ListChangedAppointmentsOptions struct {
 	DepartmentID               string
 	LeaveUnprocessed           bool
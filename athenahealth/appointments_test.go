@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -208,3 +209,20 @@ func TestHTTPClient_DeleteAppointmentNote(t *testing.T) {
 	assert.NoError(err)
 	assert.True(called)
 }
+
+func TestHTTPClient_ListAllBookedAppointments(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("appointments", 15, func(i int) interface{} {
+		return map[string]string{"appointmentid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	appointments, err := athenaClient.ListAllBookedAppointments(context.Background(), &ListBookedAppointmentsOptions{}, &IteratorOptions{PageSize: 5})
+
+	assert.NoError(err)
+	assert.Len(appointments, 15)
+	assert.Equal("14", appointments[14].AppointmentID)
+}
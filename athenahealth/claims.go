@@ -229,3 +229,95 @@ func (h *HTTPClient) ListClaims(ctx context.Context, opts *ListClaimsOptions) (*
 		Pagination: makePaginationResult(out.Next, out.Previous, out.TotalCount),
 	}, nil
 }
+
+// ClaimIterator pages through a list of claims. Call Next to advance and Value to get the current
+// claim.
+type ClaimIterator struct {
+	*iterator
+}
+
+// Value returns the current claim.
+func (i *ClaimIterator) Value() *Claim {
+	v, _ := i.current.(*Claim)
+	return v
+}
+
+// claimPageFetcher returns a pageFetcher for ListClaims with opts.
+func claimPageFetcher(client Client, opts *ListClaimsOptions) pageFetcher {
+	return func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListClaimsOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListClaims(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.Claims))
+		for i, item := range res.Claims {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+}
+
+// NewClaimIterator returns an iterator over claims, fetching pages from ListClaims as needed.
+func NewClaimIterator(client Client, opts *ListClaimsOptions, iterOpts *IteratorOptions) *ClaimIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	return &ClaimIterator{newIterator(claimPageFetcher(client, opts), start, iterOpts)}
+}
+
+// ListAllClaims returns claims, fetching pages until there are no more, iterOpts.MaxItems is
+// reached or ctx is done. On error, the results fetched so far are returned with it.
+func (h *HTTPClient) ListAllClaims(ctx context.Context, opts *ListClaimsOptions, iterOpts *IteratorOptions) ([]*Claim, error) {
+	it := NewClaimIterator(h, opts, iterOpts)
+
+	var out []*Claim
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
+
+// FetchAllClaimsResult is the result of FetchAllClaims.
+type FetchAllClaimsResult struct {
+	Claims []*Claim
+
+	// Warnings is non-empty if the total count changed while pages were being fetched.
+	Warnings []*PaginationConsistencyWarning
+}
+
+// FetchAllClaims returns all claims matching opts. After the first page it fetches the remaining pages
+// concurrently, bounded by fetchOpts.Workers, and returns the results in order.
+func (h *HTTPClient) FetchAllClaims(ctx context.Context, opts *ListClaimsOptions, fetchOpts *FetchAllPagesOptions) (*FetchAllClaimsResult, error) {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	items, warnings, err := fetchAllPages(ctx, claimPageFetcher(h, opts), start, fetchOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logConsistencyWarnings("/claims", warnings)
+
+	claims := make([]*Claim, len(items))
+	for i, item := range items {
+		claims[i] = item.(*Claim)
+	}
+
+	return &FetchAllClaimsResult{
+		Claims:   claims,
+		Warnings: warnings,
+	}, nil
+}
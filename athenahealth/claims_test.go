@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -150,3 +151,36 @@ func TestHTTPClient_ListClaims(t *testing.T) {
 	assert.Equal(res.Pagination.TotalCount, 1)
 	assert.NoError(err)
 }
+
+func TestHTTPClient_ListAllClaims(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("claims", 15, func(i int) interface{} {
+		return map[string]string{"claimid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	claims, err := athenaClient.ListAllClaims(context.Background(), &ListClaimsOptions{}, &IteratorOptions{PageSize: 5})
+
+	assert.NoError(err)
+	assert.Len(claims, 15)
+}
+
+func TestHTTPClient_FetchAllClaims(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("claims", 25, func(i int) interface{} {
+		return map[string]string{"claimid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	res, err := athenaClient.FetchAllClaims(context.Background(), &ListClaimsOptions{}, &FetchAllPagesOptions{PageSize: 10})
+
+	assert.NoError(err)
+	assert.Len(res.Claims, 25)
+	assert.Equal("24", res.Claims[24].ClaimID)
+}
@@ -3,15 +3,25 @@ package athenahealth
 import (
 	"context"
 	"time"
+
+	"github.com/asatish/go-athenahealth/athenahealth/quota"
+	"github.com/asatish/go-athenahealth/athenahealth/ratelimiter"
+	"github.com/asatish/go-athenahealth/athenahealth/stats"
 )
 
 // Client describes a client for the athenahealth API.
 type Client interface {
+	GetPracticeInfo(ctx context.Context, practiceID string) (*Practice, error)
+	ListPractices(context.Context, *ListPracticesOptions) (*ListPracticesResult, error)
+
 	GetDepartment(ctx context.Context, departmentID string) (*Department, error)
 	ListDepartments(context.Context, *ListDepartmentsOptions) (*ListDepartmentsResult, error)
+	ListAllDepartments(ctx context.Context, opts *ListDepartmentsOptions, iterOpts *IteratorOptions) ([]*Department, error)
 
 	GetPatient(ctx context.Context, patientID string, opts *GetPatientOptions) (*Patient, error)
 	ListPatients(context.Context, *ListPatientsOptions) (*ListPatientsResult, error)
+	ListAllPatients(ctx context.Context, opts *ListPatientsOptions, iterOpts *IteratorOptions) ([]*Patient, error)
+	FetchAllPatients(ctx context.Context, opts *ListPatientsOptions, fetchOpts *FetchAllPagesOptions) (*FetchAllPatientsResult, error)
 	UpdatePatientInformationVerificationDetails(ctx context.Context, patientID string, opts *UpdatePatientInformationVerificationDetailsOptions) error
 
 	ListSocialHistoryTemplates(context.Context) ([]*SocialHistoryTemplate, error)
@@ -20,6 +30,7 @@ type Client interface {
 
 	GetAppointment(ctx context.Context, appointmentID string) (*Appointment, error)
 	ListBookedAppointments(context.Context, *ListBookedAppointmentsOptions) (*ListBookedAppointmentsResult, error)
+	ListAllBookedAppointments(ctx context.Context, opts *ListBookedAppointmentsOptions, iterOpts *IteratorOptions) ([]*BookedAppointment, error)
 	ListChangedAppointments(context.Context, *ListChangedAppointmentsOptions) ([]*BookedAppointment, error)
 
 	ListAppointmentCustomFields(context.Context) ([]*AppointmentCustomField, error)
@@ -30,6 +41,7 @@ type Client interface {
 	UpdateAppointmentNote(ctx context.Context, appointmentID string, noteID string, opts *UpdateAppointmentNoteOptions) error
 
 	ListProviders(context.Context, *ListProvidersOptions) (*ListProvidersResult, error)
+	ListAllProviders(ctx context.Context, opts *ListProvidersOptions, iterOpts *IteratorOptions) ([]*Provider, error)
 	GetProvider(ctx context.Context, providerID string) (*Provider, error)
 
 	GetSubscription(ctx context.Context, feedType string) (*Subscription, error)
@@ -46,9 +58,11 @@ type Client interface {
 
 	ListProblems(ctx context.Context, patientID string, opts *ListProblemsOptions) ([]*Problem, error)
 	ListAdminDocuments(ctx context.Context, patientID string, opts *ListAdminDocumentsOptions) (*ListAdminDocumentsResult, error)
+	ListAllAdminDocuments(ctx context.Context, patientID string, opts *ListAdminDocumentsOptions, iterOpts *IteratorOptions) ([]*AdminDocument, error)
 	AddDocument(ctx context.Context, patientID string, opts *AddDocumentOptions) (string, error)
 
 	ListPatientsMatchingCustomField(ctx context.Context, opts *ListPatientsMatchingCustomFieldOptions) (*ListPatientsMatchingCustomFieldResult, error)
+	ListAllPatientsMatchingCustomField(ctx context.Context, opts *ListPatientsMatchingCustomFieldOptions, iterOpts *IteratorOptions) ([]*Patient, error)
 
 	GetPatientCustomFields(ctx context.Context, patientID, departmentID string) ([]*CustomFieldValue, error)
 	UpdatePatientCustomFields(ctx context.Context, patientID, departmentID string, customFields []*CustomFieldValue) error
@@ -58,9 +72,12 @@ type Client interface {
 	CreatePatient(ctx context.Context, opts *CreatePatientOptions) (string, error)
 
 	ListClaims(ctx context.Context, opts *ListClaimsOptions) (*ListClaimsResult, error)
+	ListAllClaims(ctx context.Context, opts *ListClaimsOptions, iterOpts *IteratorOptions) ([]*Claim, error)
+	FetchAllClaims(ctx context.Context, opts *ListClaimsOptions, fetchOpts *FetchAllPagesOptions) (*FetchAllClaimsResult, error)
 
 	CreatePatientInsurancePackage(ctx context.Context, opts *CreatePatientInsurancePackageOptions) (*InsurancePackage, error)
 	ListPatientInsurancePackages(ctx context.Context, opts *ListPatientInsurancePackagesOptions) (*ListPatientInsurancePackagesResult, error)
+	ListAllPatientInsurancePackages(ctx context.Context, opts *ListPatientInsurancePackagesOptions, iterOpts *IteratorOptions) ([]*InsurancePackage, error)
 }
 
 type TokenProvider interface {
@@ -72,12 +89,61 @@ type TokenCacher interface {
 	Set(context.Context, string, time.Time) error
 }
 
+// TokenInvalidator is an optional interface for TokenCachers that can discard the cached token,
+// e.g. after it is rejected by athenahealth.
+type TokenInvalidator interface {
+	Invalidate(context.Context) error
+}
+
+// TokenRefreshLocker is an optional interface for TokenCachers shared by several processes.
+// HTTPClient holds the lock while it fetches and caches a new token, so that only one process
+// calls the TokenProvider at a time and the others wait and then read the token it cached.
+type TokenRefreshLocker interface {
+	LockRefresh(ctx context.Context) (unlock func(), err error)
+}
+
+// TokenNamespacer is an optional interface for TokenCachers that can be shared by clients for
+// different environments or credentials. HTTPClient sets the namespace to its client ID and
+// environment whenever it is given the cacher or its environment changes, so that those clients
+// don't overwrite each other's tokens.
+type TokenNamespacer interface {
+	SetNamespace(clientID string, preview bool)
+}
+
 type RateLimiter interface {
 	Allowed(ctx context.Context, preview bool) (retryAfter time.Duration, err error)
 }
 
+// RateLimitFeedbackReceiver is an optional interface for RateLimiters that adjust their rate
+// based on athenahealth's responses. Feedback is called for every attempt that gets a response.
+type RateLimitFeedbackReceiver interface {
+	Feedback(ctx context.Context, preview bool, feedback *ratelimiter.Feedback) error
+}
+
+// QuotaTracker counts calls per endpoint template against daily and monthly budgets.
+type QuotaTracker interface {
+	// Track counts a call to the endpoint template path before it is made. It returns an error
+	// wrapping quota.ErrQuotaExceeded if the call would go over a hard limit.
+	Track(ctx context.Context, path string) (*quota.Usage, error)
+}
+
 type Stats interface {
 	Request(method, path string) error
 	ResponseSuccess() error
 	ResponseError() error
 }
+
+// PracticeStats is an optional interface for Stats that count requests per practice. HTTPClient
+// calls PracticeRequest, with the practice the request is made for, instead of Request. The
+// practice ID is empty for requests that aren't made for a practice, e.g. ListPractices.
+type PracticeStats interface {
+	PracticeRequest(practiceID, method, path string) error
+}
+
+// StatsObserver is an optional interface for Stats that receive a single observation for every
+// completed request, after all of its retries. Request, ResponseSuccess and ResponseError are
+// still called for every attempt. Use stats.ObserverFunc for an implementation that only handles
+// observations.
+type StatsObserver interface {
+	Observe(*stats.Observation) error
+}
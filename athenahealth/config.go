@@ -0,0 +1,96 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/asatish/go-athenahealth/athenahealth/tokenprovider"
+)
+
+// ErrInvalidConfig is wrapped by the errors NewHTTPClientFromConfig returns for invalid
+// configuration.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config configures an HTTPClient built by NewHTTPClientFromConfig. Only Credentials is required.
+type Config struct {
+	Credentials CredentialSource
+
+	Preview bool
+
+	// BaseURL replaces PreviewBaseURL or ProdBaseURL. It must be an absolute http or https URL.
+	BaseURL string
+
+	// RateLimiter and TokenCacher replace the defaults if they are set.
+	RateLimiter RateLimiter
+	TokenCacher TokenCacher
+}
+
+func (c *Config) validate() error {
+	if c.Credentials == nil {
+		return fmt.Errorf("%w: credential source required", ErrInvalidConfig)
+	}
+
+	if len(c.BaseURL) > 0 {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("%w: base URL %q must be an absolute http or https URL", ErrInvalidConfig, c.BaseURL)
+		}
+	}
+
+	return nil
+}
+
+// NewHTTPClientFromConfig returns an HTTPClient configured by config. It reads the credentials
+// from config.Credentials straight away and returns an error wrapping ErrInvalidConfig if the
+// config or the credentials are incomplete, so that a misconfigured service fails at startup
+// instead of on its first request. The token provider reads the credentials again for every new
+// token, so a rotated secret is picked up without a restart.
+func NewHTTPClientFromConfig(ctx context.Context, httpClient *http.Client, config *Config) (*HTTPClient, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: config required", ErrInvalidConfig)
+	}
+
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := config.Credentials.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = creds.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewHTTPClient(httpClient, creds.PracticeID, creds.ClientID, creds.Secret).
+		WithPreview(config.Preview)
+
+	c.WithTokenProvider(tokenprovider.NewRotatingFunc(httpClient, func(ctx context.Context) ([]tokenprovider.Credential, error) {
+		creds, err := config.Credentials.Credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return []tokenprovider.Credential{{ClientID: creds.ClientID, Secret: creds.Secret}}, nil
+	}, config.Preview))
+
+	if len(config.BaseURL) > 0 {
+		c.WithBaseURL(config.BaseURL)
+	}
+
+	if config.RateLimiter != nil {
+		c.WithRateLimiter(config.RateLimiter)
+	}
+
+	if config.TokenCacher != nil {
+		c.WithTokenCacher(config.TokenCacher)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,73 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/asatish/go-athenahealth/athenahealth/ratelimiter"
+	"github.com/asatish/go-athenahealth/athenahealth/tokencacher"
+	"github.com/asatish/go-athenahealth/athenahealth/tokenprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPClientFromConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	source := CredentialSourceFunc(func(context.Context) (*Credentials, error) {
+		return &Credentials{
+			PracticeID: "123",
+			ClientID:   "client-id",
+			Secret:     "secret",
+		}, nil
+	})
+
+	rateLimiter := ratelimiter.NewDefault()
+	tokenCacher := tokencacher.NewDefault()
+
+	athenaClient, err := NewHTTPClientFromConfig(context.Background(), &http.Client{}, &Config{
+		Credentials: source,
+		Preview:     true,
+		RateLimiter: rateLimiter,
+		TokenCacher: tokenCacher,
+	})
+	assert.NoError(err)
+
+	assert.Equal("123", athenaClient.practiceID)
+	assert.True(athenaClient.preview)
+	assert.Equal(PreviewBaseURL+"123", athenaClient.baseURL)
+	assert.Equal(rateLimiter, athenaClient.rateLimiter)
+	assert.Equal(tokenCacher, athenaClient.tokenCacher)
+	assert.IsType(&tokenprovider.Rotating{}, athenaClient.tokenProvider)
+
+	athenaClient, err = NewHTTPClientFromConfig(context.Background(), &http.Client{}, &Config{
+		Credentials: source,
+		BaseURL:     "https://athena-proxy.internal/v1/",
+	})
+	assert.NoError(err)
+	assert.Equal("https://athena-proxy.internal/v1/123", athenaClient.baseURL)
+}
+
+func TestNewHTTPClientFromConfig_invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	source := CredentialSourceFunc(func(context.Context) (*Credentials, error) {
+		return &Credentials{PracticeID: "123", ClientID: "client-id"}, nil
+	})
+
+	configs := []*Config{
+		nil,
+		{},
+		{Credentials: source},
+		{Credentials: NewEnvCredentialSource("ATHENA_UNSET"), BaseURL: "https://example.com"},
+		{Credentials: source, BaseURL: "/v1/"},
+		{Credentials: source, BaseURL: "ftp://example.com/"},
+	}
+
+	for i, config := range configs {
+		athenaClient, err := NewHTTPClientFromConfig(context.Background(), &http.Client{}, config)
+		assert.Nil(athenaClient, i)
+		assert.True(errors.Is(err, ErrInvalidConfig), i)
+	}
+}
@@ -0,0 +1,121 @@
+package athenahealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCredentialEnvPrefix is the prefix of the environment variables read by EnvCredentialSource
+// if it isn't given one.
+const DefaultCredentialEnvPrefix = "ATHENA"
+
+// Credentials are what HTTPClient needs to authenticate with athenahealth.
+type Credentials struct {
+	PracticeID string `json:"practiceID" yaml:"practiceID"`
+	ClientID   string `json:"clientID" yaml:"clientID"`
+	Secret     string `json:"secret" yaml:"secret"`
+}
+
+func (c *Credentials) validate() error {
+	var missing []string
+
+	if len(c.PracticeID) == 0 {
+		missing = append(missing, "practice ID")
+	}
+	if len(c.ClientID) == 0 {
+		missing = append(missing, "client ID")
+	}
+	if len(c.Secret) == 0 {
+		missing = append(missing, "secret")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInvalidConfig, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// CredentialSource returns Credentials, e.g. from the environment, a file or a secret manager.
+type CredentialSource interface {
+	Credentials(context.Context) (*Credentials, error)
+}
+
+// CredentialSourceFunc adapts a function, e.g. one that reads from a secret manager, to a
+// CredentialSource.
+type CredentialSourceFunc func(context.Context) (*Credentials, error)
+
+func (f CredentialSourceFunc) Credentials(ctx context.Context) (*Credentials, error) {
+	return f(ctx)
+}
+
+// EnvCredentialSource reads Credentials from the <prefix>_PRACTICE_ID, <prefix>_CLIENT_ID and
+// <prefix>_SECRET environment variables.
+type EnvCredentialSource struct {
+	prefix string
+}
+
+// NewEnvCredentialSource returns an EnvCredentialSource for prefix, or DefaultCredentialEnvPrefix
+// if prefix is empty.
+func NewEnvCredentialSource(prefix string) *EnvCredentialSource {
+	if len(prefix) == 0 {
+		prefix = DefaultCredentialEnvPrefix
+	}
+
+	return &EnvCredentialSource{
+		prefix: prefix,
+	}
+}
+
+func (e *EnvCredentialSource) Credentials(ctx context.Context) (*Credentials, error) {
+	return &Credentials{
+		PracticeID: os.Getenv(e.prefix + "_PRACTICE_ID"),
+		ClientID:   os.Getenv(e.prefix + "_CLIENT_ID"),
+		Secret:     os.Getenv(e.prefix + "_SECRET"),
+	}, nil
+}
+
+// FileCredentialSource reads Credentials from a JSON or YAML file. The format is picked by the
+// file's extension: .yaml and .yml files are YAML and anything else is JSON. The file is read on
+// every call, so a rotated secret is picked up without a restart.
+type FileCredentialSource struct {
+	path string
+}
+
+func NewFileCredentialSource(path string) *FileCredentialSource {
+	if len(path) == 0 {
+		panic("path required")
+	}
+
+	return &FileCredentialSource{
+		path: path,
+	}
+}
+
+func (f *FileCredentialSource) Credentials(ctx context.Context) (*Credentials, error) {
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Credentials{}
+
+	switch strings.ToLower(filepath.Ext(f.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, c)
+	default:
+		err = json.Unmarshal(b, c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error unmarshaling credentials file %s: %s", f.path, err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,100 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvCredentialSource(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv("ATHENA_TEST_PRACTICE_ID", "123")
+	os.Setenv("ATHENA_TEST_CLIENT_ID", "client-id")
+	os.Setenv("ATHENA_TEST_SECRET", "secret")
+	defer func() {
+		os.Unsetenv("ATHENA_TEST_PRACTICE_ID")
+		os.Unsetenv("ATHENA_TEST_CLIENT_ID")
+		os.Unsetenv("ATHENA_TEST_SECRET")
+	}()
+
+	creds, err := NewEnvCredentialSource("ATHENA_TEST").Credentials(context.Background())
+	assert.NoError(err)
+	assert.Equal(&Credentials{
+		PracticeID: "123",
+		ClientID:   "client-id",
+		Secret:     "secret",
+	}, creds)
+
+	assert.Equal(DefaultCredentialEnvPrefix, NewEnvCredentialSource("").prefix)
+}
+
+func TestFileCredentialSource(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "athena_credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"credentials.json": `{"practiceID": "123", "clientID": "client-id", "secret": "secret"}`,
+		"credentials.yaml": "practiceID: \"123\"\nclientID: client-id\nsecret: secret\n",
+		"credentials.yml":  "practiceID: \"123\"\nclientID: client-id\nsecret: secret\n",
+	}
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(ioutil.WriteFile(path, []byte(contents), 0600))
+
+		creds, err := NewFileCredentialSource(path).Credentials(context.Background())
+		assert.NoError(err, name)
+		assert.Equal(&Credentials{
+			PracticeID: "123",
+			ClientID:   "client-id",
+			Secret:     "secret",
+		}, creds, name)
+	}
+
+	path := filepath.Join(dir, "invalid.json")
+	assert.NoError(ioutil.WriteFile(path, []byte("practiceID: 123"), 0600))
+
+	_, err = NewFileCredentialSource(path).Credentials(context.Background())
+	assert.Error(err)
+
+	_, err = NewFileCredentialSource(filepath.Join(dir, "missing.json")).Credentials(context.Background())
+	assert.True(os.IsNotExist(err))
+
+	assert.Panics(func() {
+		NewFileCredentialSource("")
+	})
+}
+
+func TestCredentialSourceFunc(t *testing.T) {
+	assert := assert.New(t)
+
+	sourceErr := errors.New("secret manager unavailable")
+
+	var source CredentialSource = CredentialSourceFunc(func(context.Context) (*Credentials, error) {
+		return nil, sourceErr
+	})
+
+	_, err := source.Credentials(context.Background())
+	assert.Equal(sourceErr, err)
+}
+
+func TestCredentials_validate(t *testing.T) {
+	assert := assert.New(t)
+
+	err := (&Credentials{PracticeID: "123"}).validate()
+	assert.True(errors.Is(err, ErrInvalidConfig))
+	assert.Contains(err.Error(), "client ID, secret")
+
+	assert.NoError((&Credentials{PracticeID: "123", ClientID: "client-id", Secret: "secret"}).validate())
+}
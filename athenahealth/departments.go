@@ -118,3 +118,59 @@ func (h *HTTPClient) ListDepartments(ctx context.Context, opts *ListDepartmentsO
 		Pagination:  makePaginationResult(out.Next, out.Previous, out.TotalCount),
 	}, nil
 }
+
+// DepartmentIterator pages through a list of departments. Call Next to advance and Value to get the
+// current department.
+type DepartmentIterator struct {
+	*iterator
+}
+
+// Value returns the current department.
+func (i *DepartmentIterator) Value() *Department {
+	v, _ := i.current.(*Department)
+	return v
+}
+
+// NewDepartmentIterator returns an iterator over departments, fetching pages from ListDepartments
+// as needed.
+func NewDepartmentIterator(client Client, opts *ListDepartmentsOptions, iterOpts *IteratorOptions) *DepartmentIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListDepartmentsOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListDepartments(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.Departments))
+		for i, item := range res.Departments {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+
+	return &DepartmentIterator{newIterator(fetch, start, iterOpts)}
+}
+
+// ListAllDepartments returns departments, fetching pages until there are no more, iterOpts.MaxItems
+// is reached or ctx is done. On error, the results fetched so far are returned with it.
+func (h *HTTPClient) ListAllDepartments(ctx context.Context, opts *ListDepartmentsOptions, iterOpts *IteratorOptions) ([]*Department, error) {
+	it := NewDepartmentIterator(h, opts, iterOpts)
+
+	var out []*Department
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
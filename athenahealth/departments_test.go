@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +56,20 @@ func TestHTTPClient_ListDepartments(t *testing.T) {
 	assert.Equal(res.Pagination.TotalCount, 1)
 	assert.NoError(err)
 }
+
+func TestHTTPClient_ListAllDepartments(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("departments", 15, func(i int) interface{} {
+		return map[string]string{"departmentid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	departments, err := athenaClient.ListAllDepartments(context.Background(), nil, nil)
+
+	assert.NoError(err)
+	assert.Len(departments, 15)
+	assert.Equal("14", departments[14].DepartmentID)
+}
@@ -84,6 +84,63 @@ func (h *HTTPClient) ListAdminDocuments(ctx context.Context, patientID string, o
 	}, nil
 }
 
+// AdminDocumentIterator pages through a list of documents. Call Next to advance and Value to get
+// the current document.
+type AdminDocumentIterator struct {
+	*iterator
+}
+
+// Value returns the current document.
+func (i *AdminDocumentIterator) Value() *AdminDocument {
+	v, _ := i.current.(*AdminDocument)
+	return v
+}
+
+// NewAdminDocumentIterator returns an iterator over a patient's admin documents, fetching pages
+// from ListAdminDocuments as needed.
+func NewAdminDocumentIterator(client Client, patientID string, opts *ListAdminDocumentsOptions, iterOpts *IteratorOptions) *AdminDocumentIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListAdminDocumentsOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListAdminDocuments(ctx, patientID, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.AdminDocuments))
+		for i, item := range res.AdminDocuments {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+
+	return &AdminDocumentIterator{newIterator(fetch, start, iterOpts)}
+}
+
+// ListAllAdminDocuments returns a patient's admin documents, fetching pages until there are no
+// more, iterOpts.MaxItems is reached or ctx is done. On error, the results fetched so far are
+// returned with it.
+func (h *HTTPClient) ListAllAdminDocuments(ctx context.Context, patientID string, opts *ListAdminDocumentsOptions, iterOpts *IteratorOptions) ([]*AdminDocument, error) {
+	it := NewAdminDocumentIterator(h, patientID, opts, iterOpts)
+
+	var out []*AdminDocument
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
+
 type AddDocumentOptions struct {
 	ActionNote         *string
 	AppointmentID      *int
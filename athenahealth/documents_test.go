@@ -87,3 +87,19 @@ func TestHTTPClient_AddDocument(t *testing.T) {
 	assert.Equal("100", documentID)
 	assert.NoError(err)
 }
+
+func TestHTTPClient_ListAllAdminDocuments(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("admins", 15, func(i int) interface{} {
+		return map[string]int{"adminid": i}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	docs, err := athenaClient.ListAllAdminDocuments(context.Background(), "1", nil, &IteratorOptions{PageSize: 5})
+
+	assert.NoError(err)
+	assert.Len(docs, 15)
+}
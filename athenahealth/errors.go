@@ -1,5 +1,185 @@
 package athenahealth
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
 
 var ErrNotFound = errors.New("not found")
+var ErrBadRequest = errors.New("bad request")
+var ErrUnauthorized = errors.New("unauthorized")
+var ErrForbidden = errors.New("forbidden")
+var ErrConflict = errors.New("conflict")
+var ErrRateLimited = errors.New("rate limited")
+var ErrServerError = errors.New("server error")
+var ErrRateLimitWaitExceeded = errors.New("rate limit wait exceeded")
+
+// statusErrors maps HTTP status codes to the sentinel errors wrapped by APIError.
+var statusErrors = map[int]error{
+	http.StatusBadRequest:      ErrBadRequest,
+	http.StatusUnauthorized:    ErrUnauthorized,
+	http.StatusForbidden:       ErrForbidden,
+	http.StatusNotFound:        ErrNotFound,
+	http.StatusConflict:        ErrConflict,
+	http.StatusTooManyRequests: ErrRateLimited,
+}
+
+// statusError returns the sentinel error for an HTTP status code, or nil if there isn't one.
+func statusError(statusCode int) error {
+	if err, ok := statusErrors[statusCode]; ok {
+		return err
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		return ErrServerError
+	}
+
+	return nil
+}
+
+const (
+	// ValidationReasonMissing means a required field was not provided.
+	ValidationReasonMissing = "missing"
+
+	// ValidationReasonInvalid means a field's value was rejected.
+	ValidationReasonInvalid = "invalid"
+)
+
+// ValidationError describes a problem with a single field of a request.
+type ValidationError struct {
+	Field string
+
+	// Reason is ValidationReasonMissing or ValidationReasonInvalid.
+	Reason string
+
+	// Message is athenahealth's message for the field, if it returned one.
+	Message string
+}
+
+func (v *ValidationError) Error() string {
+	if len(v.Message) > 0 {
+		return fmt.Sprintf("%s field %s: %s", v.Reason, v.Field, v.Message)
+	}
+
+	return fmt.Sprintf("%s field %s", v.Reason, v.Field)
+}
+
+// ValidationErrors are the field-level problems athenahealth reported for a request.
+type ValidationErrors []*ValidationError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = e.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// fieldList is a list of field names that athenahealth returns either as a JSON array or as a
+// comma separated string.
+type fieldList []string
+
+func (f *fieldList) UnmarshalJSON(data []byte) error {
+	var list []string
+
+	err := json.Unmarshal(data, &list)
+	if err == nil {
+		*f = list
+		return nil
+	}
+
+	var s string
+
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*f = nil
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if len(field) > 0 {
+			*f = append(*f, field)
+		}
+	}
+
+	return nil
+}
+
+// parseAPIError builds an APIError from an error response and its body.
+func parseAPIError(res *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		Err:          statusError(res.StatusCode),
+		HTTPResponse: res,
+	}
+
+	aux := &struct {
+		AthenaError           string    `json:"error"`
+		AthenaDetailedMessage string    `json:"detailedmessage"`
+		MissingFields         fieldList `json:"missingfields"`
+		InvalidFields         fieldList `json:"invalidfields"`
+	}{}
+
+	err := json.Unmarshal(body, aux)
+	if err != nil {
+		return apiErr
+	}
+
+	apiErr.AthenaError = aux.AthenaError
+	apiErr.AthenaDetailedMessage = aux.AthenaDetailedMessage
+	apiErr.MissingFields = aux.MissingFields
+	apiErr.InvalidFields = aux.InvalidFields
+
+	if len(aux.MissingFields) == 0 && len(aux.InvalidFields) == 0 {
+		return apiErr
+	}
+
+	// athenahealth may return a message for a field under the field's name.
+	fields := map[string]json.RawMessage{}
+	json.Unmarshal(body, &fields)
+
+	fieldMessage := func(field string) string {
+		var msg string
+		json.Unmarshal(fields[field], &msg)
+
+		return msg
+	}
+
+	for _, field := range aux.MissingFields {
+		apiErr.ValidationErrors = append(apiErr.ValidationErrors, &ValidationError{
+			Field:   field,
+			Reason:  ValidationReasonMissing,
+			Message: fieldMessage(field),
+		})
+	}
+
+	for _, field := range aux.InvalidFields {
+		apiErr.ValidationErrors = append(apiErr.ValidationErrors, &ValidationError{
+			Field:   field,
+			Reason:  ValidationReasonInvalid,
+			Message: fieldMessage(field),
+		})
+	}
+
+	return apiErr
+}
+
+// RateLimitWaitError is returned when waiting for the rate limiter would exceed the maximum wait
+// configured with WithMaxRateLimitWait.
+type RateLimitWaitError struct {
+	// Waited is how long the request already waited for the rate limiter.
+	Waited time.Duration
+}
+
+func (r *RateLimitWaitError) Error() string {
+	return fmt.Sprintf("%s (waited %s)", ErrRateLimitWaitExceeded, r.Waited)
+}
+
+func (r *RateLimitWaitError) Unwrap() error {
+	return ErrRateLimitWaitExceeded
+}
@@ -0,0 +1,84 @@
+package athenahealth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_statusError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(ErrBadRequest, statusError(http.StatusBadRequest))
+	assert.Equal(ErrUnauthorized, statusError(http.StatusUnauthorized))
+	assert.Equal(ErrForbidden, statusError(http.StatusForbidden))
+	assert.Equal(ErrNotFound, statusError(http.StatusNotFound))
+	assert.Equal(ErrConflict, statusError(http.StatusConflict))
+	assert.Equal(ErrRateLimited, statusError(http.StatusTooManyRequests))
+	assert.Equal(ErrServerError, statusError(http.StatusInternalServerError))
+	assert.Equal(ErrServerError, statusError(http.StatusServiceUnavailable))
+	assert.Nil(statusError(http.StatusTeapot))
+}
+
+func Test_parseAPIError(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &http.Response{
+		Status:     "400 Bad Request",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	body := []byte(`{
+		"error": "Additional fields are required.",
+		"detailedmessage": "Please check the fields.",
+		"missingfields": ["dob", "sex"],
+		"invalidfields": ["zip"],
+		"zip": "Zip code must be 5 digits."
+	}`)
+
+	err := parseAPIError(res, body)
+
+	assert.True(errors.Is(err, ErrBadRequest))
+	assert.Equal("Additional fields are required.", err.AthenaError)
+	assert.Equal("Please check the fields.", err.AthenaDetailedMessage)
+	assert.Equal([]string{"dob", "sex"}, err.MissingFields)
+	assert.Equal([]string{"zip"}, err.InvalidFields)
+	assert.Equal(ValidationErrors{
+		{Field: "dob", Reason: ValidationReasonMissing},
+		{Field: "sex", Reason: ValidationReasonMissing},
+		{Field: "zip", Reason: ValidationReasonInvalid, Message: "Zip code must be 5 digits."},
+	}, err.ValidationErrors)
+	assert.Equal(res, err.HTTPResponse)
+
+	assert.Contains(err.Error(), "missing field dob")
+	assert.Contains(err.Error(), "invalid field zip: Zip code must be 5 digits.")
+}
+
+func Test_parseAPIError_fieldString(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &http.Response{
+		StatusCode: http.StatusBadRequest,
+	}
+
+	err := parseAPIError(res, []byte(`{"error": "Invalid fields.", "invalidfields": "dob, sex"}`))
+
+	assert.Equal([]string{"dob", "sex"}, err.InvalidFields)
+	assert.Len(err.ValidationErrors, 2)
+}
+
+func Test_parseAPIError_invalidBody(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &http.Response{
+		StatusCode: http.StatusBadGateway,
+	}
+
+	err := parseAPIError(res, []byte(`<html>Bad Gateway</html>`))
+
+	assert.True(errors.Is(err, ErrServerError))
+	assert.Empty(err.AthenaError)
+	assert.Empty(err.ValidationErrors)
+}
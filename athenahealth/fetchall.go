@@ -0,0 +1,214 @@
+package athenahealth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultFetchAllWorkers is the number of pages fetched concurrently if FetchAllPagesOptions
+// doesn't specify one.
+const defaultFetchAllWorkers = 4
+
+// FetchAllPagesOptions controls how FetchAll* helpers fetch pages concurrently.
+type FetchAllPagesOptions struct {
+	// PageSize is the number of results requested per page. Zero uses the limit in the list
+	// options' Pagination, or the size of the first page athenahealth returns.
+	PageSize int
+
+	// Workers is the maximum number of pages fetched at the same time. Every page still goes
+	// through the client's rate limiter.
+	Workers int
+}
+
+// PaginationConsistencyWarning reports that the total count of a list changed while its pages
+// were being fetched. Results may be missing or duplicated when that happens.
+type PaginationConsistencyWarning struct {
+	// Offset is the offset of the page that reported the new total count.
+	Offset int
+
+	// ExpectedTotalCount is the total count reported by the first page.
+	ExpectedTotalCount int
+
+	// TotalCount is the total count reported by the page at Offset.
+	TotalCount int
+}
+
+func (p *PaginationConsistencyWarning) String() string {
+	return fmt.Sprintf("total count changed from %d to %d at offset %d", p.ExpectedTotalCount, p.TotalCount, p.Offset)
+}
+
+type fetchedPage struct {
+	items   []interface{}
+	warning *PaginationConsistencyWarning
+}
+
+// fetchAllPages fetches the first page, then uses its TotalCount to fetch the remaining pages
+// concurrently with at most opts.Workers goroutines. If the TotalCount doesn't reach past the
+// first page, the remaining pages are fetched one at a time instead. Results are returned in the
+// order athenahealth returns them.
+func fetchAllPages(ctx context.Context, fetch pageFetcher, start *PaginationOptions, opts *FetchAllPagesOptions) ([]interface{}, []*PaginationConsistencyWarning, error) {
+	pagination := &PaginationOptions{}
+	if start != nil {
+		*pagination = *start
+	}
+
+	workers := defaultFetchAllWorkers
+
+	if opts != nil {
+		if opts.PageSize > 0 {
+			pagination.Limit = opts.PageSize
+		}
+
+		if opts.Workers > 0 {
+			workers = opts.Workers
+		}
+	}
+
+	items, first, err := fetch(ctx, pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(items) == 0 || first == nil || first.NextOffset <= pagination.Offset {
+		return items, nil, nil
+	}
+
+	pageSize := first.NextOffset - pagination.Offset
+	totalCount := first.TotalCount
+
+	// Without a total count that covers the next page there's no way to know which offsets to
+	// fetch, so follow the next offsets one page at a time instead.
+	if totalCount <= first.NextOffset {
+		it := newIterator(fetch, &PaginationOptions{
+			Limit:  pageSize,
+			Offset: first.NextOffset,
+		}, nil)
+
+		for it.Next(ctx) {
+			items = append(items, it.current)
+		}
+
+		if err := it.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		return items, nil, nil
+	}
+
+	var offsets []int
+	for offset := first.NextOffset; offset < totalCount; offset += pageSize {
+		offsets = append(offsets, offset)
+	}
+
+	if workers > len(offsets) {
+		workers = len(offsets)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([]*fetchedPage, len(offsets))
+
+	// indexes feeds the workers the index in offsets of each page to fetch.
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	// Keep the first error. The ones after it are usually caused by the cancellation.
+	var fetchErr error
+	var errOnce sync.Once
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			fetchErr = err
+			cancel()
+		})
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				// Skip the remaining pages once the fetch failed or the caller gave up. Record
+				// the caller's error, since its pages are never fetched.
+				if err := ctx.Err(); err != nil {
+					fail(err)
+					continue
+				}
+
+				offset := offsets[i]
+
+				pageItems, res, err := fetch(ctx, &PaginationOptions{
+					Limit:  pageSize,
+					Offset: offset,
+				})
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				page := &fetchedPage{
+					items: pageItems,
+				}
+				pages[i] = page
+
+				if res != nil && res.TotalCount != totalCount {
+					page.warning = &PaginationConsistencyWarning{
+						Offset:             offset,
+						ExpectedTotalCount: totalCount,
+						TotalCount:         res.TotalCount,
+					}
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range offsets {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			fail(ctx.Err())
+			break feed
+		}
+	}
+	close(indexes)
+
+	wg.Wait()
+
+	if fetchErr != nil {
+		return nil, nil, fetchErr
+	}
+
+	var warnings []*PaginationConsistencyWarning
+
+	for _, page := range pages {
+		if page.warning != nil {
+			warnings = append(warnings, page.warning)
+		}
+
+		items = append(items, page.items...)
+	}
+
+	return items, warnings, nil
+}
+
+// logConsistencyWarnings logs warnings from fetchAllPages for the list at path.
+func (h *HTTPClient) logConsistencyWarnings(path string, warnings []*PaginationConsistencyWarning) {
+	if h.logger == nil {
+		return
+	}
+
+	for _, w := range warnings {
+		h.logger.Warn().
+			Str("path", path).
+			Int("offset", w.Offset).
+			Int("expectedTotalCount", w.ExpectedTotalCount).
+			Int("totalCount", w.TotalCount).
+			Msg("athenahealth API total count changed during pagination")
+	}
+}
@@ -0,0 +1,229 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchAllPages(t *testing.T) {
+	assert := assert.New(t)
+
+	var lock sync.Mutex
+	var calls []PaginationOptions
+	inFlight := 0
+	maxInFlight := 0
+
+	total := 95
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		lock.Lock()
+		calls = append(calls, *pagination)
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		lock.Unlock()
+
+		// Finish later pages first to make sure the results are still in order.
+		time.Sleep(time.Duration(total-pagination.Offset) * time.Millisecond / 10)
+
+		lock.Lock()
+		inFlight--
+		lock.Unlock()
+
+		var items []interface{}
+		for i := pagination.Offset; i < pagination.Offset+pagination.Limit && i < total; i++ {
+			items = append(items, i)
+		}
+
+		next := ""
+		if pagination.Offset+pagination.Limit < total {
+			next = fmt.Sprintf("/?offset=%d", pagination.Offset+pagination.Limit)
+		}
+
+		return items, makePaginationResult(next, "", total), nil
+	}
+
+	items, warnings, err := fetchAllPages(context.Background(), fetch, nil, &FetchAllPagesOptions{
+		PageSize: 10,
+		Workers:  3,
+	})
+
+	assert.NoError(err)
+	assert.Empty(warnings)
+	assert.Len(items, total)
+	for i, item := range items {
+		assert.Equal(i, item)
+	}
+
+	assert.Len(calls, 10)
+	assert.True(maxInFlight <= 3)
+}
+
+func TestFetchAllPages_singlePage(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []PaginationOptions
+	items, warnings, err := fetchAllPages(context.Background(), testPageFetcher(5, &calls), nil, &FetchAllPagesOptions{PageSize: 10})
+
+	assert.NoError(err)
+	assert.Empty(warnings)
+	assert.Len(items, 5)
+	assert.Len(calls, 1)
+}
+
+func TestFetchAllPages_noTotalCount(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []PaginationOptions
+	pages := testPageFetcher(25, &calls)
+
+	// athenahealth left out the total count, so it can't be used to plan the remaining pages.
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		items, res, err := pages(ctx, pagination)
+		res.TotalCount = 0
+
+		return items, res, err
+	}
+
+	items, warnings, err := fetchAllPages(context.Background(), fetch, nil, &FetchAllPagesOptions{PageSize: 10})
+
+	assert.NoError(err)
+	assert.Empty(warnings)
+	assert.Len(items, 25)
+	assert.Equal([]PaginationOptions{
+		{Limit: 10, Offset: 0},
+		{Limit: 10, Offset: 10},
+		{Limit: 10, Offset: 20},
+	}, calls)
+}
+
+func TestFetchAllPages_totalCountChanged(t *testing.T) {
+	assert := assert.New(t)
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		total := 30
+		if pagination.Offset >= 20 {
+			total = 31
+		}
+
+		next := fmt.Sprintf("/?offset=%d", pagination.Offset+pagination.Limit)
+
+		return []interface{}{pagination.Offset}, makePaginationResult(next, "", total), nil
+	}
+
+	items, warnings, err := fetchAllPages(context.Background(), fetch, nil, &FetchAllPagesOptions{PageSize: 10})
+
+	assert.NoError(err)
+	assert.Len(items, 3)
+	assert.Equal([]*PaginationConsistencyWarning{
+		{Offset: 20, ExpectedTotalCount: 30, TotalCount: 31},
+	}, warnings)
+}
+
+func TestFetchAllPages_error(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("boom")
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		if pagination.Offset == 50 {
+			return nil, nil, expectedErr
+		}
+
+		next := fmt.Sprintf("/?offset=%d", pagination.Offset+pagination.Limit)
+
+		return []interface{}{pagination.Offset}, makePaginationResult(next, "", 1000), nil
+	}
+
+	items, _, err := fetchAllPages(context.Background(), fetch, nil, &FetchAllPagesOptions{PageSize: 10, Workers: 1})
+
+	assert.Nil(items)
+	assert.Equal(expectedErr, err)
+}
+
+func TestFetchAllPages_canceled(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		// The caller gives up while the second page is fetched, which still succeeds.
+		if pagination.Offset == 10 {
+			cancel()
+		}
+
+		next := fmt.Sprintf("/?offset=%d", pagination.Offset+pagination.Limit)
+
+		return []interface{}{pagination.Offset}, makePaginationResult(next, "", 30), nil
+	}
+
+	items, _, err := fetchAllPages(ctx, fetch, nil, &FetchAllPagesOptions{PageSize: 10, Workers: 1})
+
+	assert.Nil(items)
+	assert.True(errors.Is(err, context.Canceled))
+}
+
+func TestFetchAllPages_workers(t *testing.T) {
+	assert := assert.New(t)
+
+	var lock sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		lock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		lock.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		lock.Lock()
+		inFlight--
+		lock.Unlock()
+
+		next := fmt.Sprintf("/?offset=%d", pagination.Offset+pagination.Limit)
+
+		return []interface{}{pagination.Offset}, makePaginationResult(next, "", 10000), nil
+	}
+
+	before := runtime.NumGoroutine()
+	peak := before
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		items, _, err := fetchAllPages(context.Background(), fetch, nil, &FetchAllPagesOptions{PageSize: 10, Workers: 4})
+		assert.NoError(err)
+		assert.Len(items, 1000)
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.True(maxInFlight <= 4)
+
+			// The goroutine above and the 4 workers, with some slack for the runtime.
+			assert.True(peak-before <= 10, "peak goroutines: %d", peak-before)
+			return
+		default:
+		}
+
+		if n := runtime.NumGoroutine(); n > peak {
+			peak = n
+		}
+
+		time.Sleep(100 * time.Microsecond)
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/asatish/go-athenahealth/athenahealth/ratelimiter"
@@ -20,6 +19,8 @@ import (
 	"github.com/asatish/go-athenahealth/athenahealth/tokencacher"
 	"github.com/asatish/go-athenahealth/athenahealth/tokenprovider"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -47,25 +48,43 @@ type HTTPClient struct {
 
 	preview bool
 
+	// apiURL replaces PreviewBaseURL or ProdBaseURL if it is set.
+	apiURL  string
 	baseURL string
 
 	tokenProvider TokenProvider
 	tokenCacher   TokenCacher
 	rateLimiter   RateLimiter
+	quotaTracker  QuotaTracker
 	stats         Stats
 	logger        *zerolog.Logger
+	retryPolicy   *RetryPolicy
 
-	requestLock sync.Mutex
+	maxRateLimitWait time.Duration
+
+	middleware []Middleware
+
+	tracer trace.Tracer
+
+	tokenFlight *flightGroup
+	refresher   *tokenRefresher
 }
 
 var _ Client = &HTTPClient{}
 
-// APIError represents an error response from the athenahealth API.
+// APIError represents an error response from the athenahealth API. Use errors.Is with the
+// sentinel errors (ErrNotFound, ErrBadRequest, etc.) to check the kind of error.
 type APIError struct {
 	Err                   error  `json:"-"`
 	AthenaError           string `json:"error"`
 	AthenaDetailedMessage string `json:"detailedmessage"`
 
+	MissingFields []string `json:"missingfields"`
+	InvalidFields []string `json:"invalidfields"`
+
+	// ValidationErrors describes each missing or invalid field, including any per-field message.
+	ValidationErrors ValidationErrors `json:"-"`
+
 	HTTPResponse *http.Response
 }
 
@@ -80,7 +99,13 @@ func (a *APIError) Error() string {
 		status = a.HTTPResponse.Status
 	}
 
-	return fmt.Sprintf("athenahealth API error (%s): %s (%s)", status, a.AthenaError, details)
+	msg := fmt.Sprintf("athenahealth API error (%s): %s (%s)", status, a.AthenaError, details)
+
+	if len(a.ValidationErrors) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, a.ValidationErrors)
+	}
+
+	return msg
 }
 
 func (a *APIError) Unwrap() error {
@@ -140,6 +165,10 @@ func NewHTTPClient(httpClient *http.Client, practiceID, clientID, secret string)
 		tokenCacher:   tokencacher.NewDefault(),
 		rateLimiter:   ratelimiter.NewDefault(),
 		stats:         stats.NewDefault(),
+
+		tracer: trace.NewNoopTracerProvider().Tracer(tracerName),
+
+		tokenFlight: &flightGroup{},
 	}
 
 	c.setBaseURL()
@@ -148,109 +177,182 @@ func NewHTTPClient(httpClient *http.Client, practiceID, clientID, secret string)
 }
 
 func (h *HTTPClient) setBaseURL() {
+	h.baseURL = fmt.Sprintf("%s%s", h.apiBaseURL(), h.practiceID)
+}
+
+// apiBaseURL returns the base URL of the API, without a practice ID.
+func (h *HTTPClient) apiBaseURL() string {
+	if len(h.apiURL) > 0 {
+		return h.apiURL
+	}
+
 	if h.preview {
-		h.baseURL = fmt.Sprintf("%s%s", PreviewBaseURL, h.practiceID)
-	} else {
-		h.baseURL = fmt.Sprintf("%s%s", ProdBaseURL, h.practiceID)
+		return PreviewBaseURL
 	}
+
+	return ProdBaseURL
 }
 
+// requestInfo collects details about a request as it is made.
+type requestInfo struct {
+	// practiceID is the practice the request is made for. It is empty for unscoped requests that
+	// aren't about a single practice.
+	practiceID string
+
+	// unscoped is set for requests whose path is relative to the API's base URL instead of the
+	// practice's.
+	unscoped bool
+
+	// retries is the number of attempts made after the first one.
+	retries int
+
+	// rateLimitWait is the total time spent waiting for the rate limiter.
+	rateLimitWait time.Duration
+
+	// requestBytes and responseBytes are the sizes of the request and final response bodies.
+	requestBytes  int
+	responseBytes int
+}
+
+// request makes a request to path relative to the practice's base URL.
 func (h *HTTPClient) request(ctx context.Context, method, path string, body io.Reader, headers http.Header, out interface{}) (*http.Response, error) {
+	info := &requestInfo{
+		practiceID: h.requestPracticeID(ctx),
+	}
+
+	return h.makeRequest(ctx, method, path, body, headers, out, info)
+}
+
+// unscopedRequest makes a request to path relative to the API's base URL instead of the
+// practice's, for endpoints that aren't scoped to the client's practice, e.g. /1/practiceinfo.
+// practiceID is the practice the request is about, if there is one. It is only used to tag the
+// request's span and stats.
+func (h *HTTPClient) unscopedRequest(ctx context.Context, practiceID, method, path string, body io.Reader, headers http.Header, out interface{}) (*http.Response, error) {
+	info := &requestInfo{
+		practiceID: practiceID,
+		unscoped:   true,
+	}
+
+	return h.makeRequest(ctx, method, path, body, headers, out, info)
+}
+
+func (h *HTTPClient) makeRequest(ctx context.Context, method, path string, body io.Reader, headers http.Header, out interface{}, info *requestInfo) (*http.Response, error) {
 	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, defaultRequestTimeout)
 		defer cancel()
 	}
 
-	var token string
-	var err error
-	var expiresAt time.Time
+	if !strings.HasPrefix(path, "/") {
+		path = fmt.Sprintf("/%s", path)
+	}
 
-	h.requestLock.Lock()
+	ctx = WithPracticeID(ctx, info.practiceID)
 
-	retryAfter, err := h.rateLimiter.Allowed(ctx, h.preview)
-	if err != nil {
-		h.requestLock.Unlock()
+	ctx, span := h.startRequestSpan(ctx, method, path, info.practiceID)
+	defer span.End()
 
-		if errors.Is(err, ratelimiter.ErrRateExceeded) {
-			time.Sleep(retryAfter)
-			return h.request(ctx, method, path, body, headers, out)
-		}
+	start := time.Now()
 
-		return nil, err
-	}
+	res, err := h.execute(ctx, method, path, body, headers, out, info)
 
-	token, err = h.tokenCacher.Get(ctx)
-	if err != nil {
-		if !errors.Is(err, tokencacher.ErrTokenNotExist) && !errors.Is(err, tokencacher.ErrTokenExpired) {
-			h.requestLock.Unlock()
-			return nil, err
-		}
+	finishRequestSpan(span, res, err, info)
+	h.observe(method, path, time.Since(start), res, err, info)
 
-		token, expiresAt, err = h.tokenProvider.Provide(ctx)
-		if err != nil {
-			h.requestLock.Unlock()
-			return nil, err
-		}
+	return res, err
+}
 
-		// Remove 1 minute from the expiration time to create a buffer to see
-		// if it resolves intermittent 401s.
-		err = h.tokenCacher.Set(context.Background(), token, expiresAt.Add(-1*time.Minute))
-		if err != nil {
-			h.requestLock.Unlock()
-			return nil, err
-		}
+// observe reports a completed request to stats if it is a StatsObserver. The request has already
+// completed, so errors are logged instead of returned.
+func (h *HTTPClient) observe(method, path string, duration time.Duration, res *http.Response, err error, info *requestInfo) {
+	observer, ok := h.stats.(StatsObserver)
+	if !ok {
+		return
 	}
 
-	h.requestLock.Unlock()
+	o := &stats.Observation{
+		PracticeID:    info.practiceID,
+		Method:        method,
+		Path:          stats.CleanPath(path),
+		Duration:      duration,
+		RequestBytes:  info.requestBytes,
+		ResponseBytes: info.responseBytes,
+		Retries:       info.retries,
+		RateLimitWait: info.rateLimitWait,
+		Err:           err,
+	}
 
-	if !strings.HasPrefix(path, "/") {
-		path = fmt.Sprintf("/%s", path)
+	if res != nil {
+		o.StatusCode = res.StatusCode
 	}
 
-	reqURL := fmt.Sprintf("%s%s", h.baseURL, path)
+	observeErr := observer.Observe(o)
+	if observeErr != nil && h.logger != nil {
+		h.logger.Warn().
+			Err(observeErr).
+			Str("practiceID", info.practiceID).
+			Str("method", method).
+			Str("path", o.Path).
+			Msg("athenahealth stats observation failed")
+	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+// execute waits for the rate limiter, authenticates and sends the request, and decodes its
+// response into out.
+func (h *HTTPClient) execute(ctx context.Context, method, path string, body io.Reader, headers http.Header, out interface{}, info *requestInfo) (*http.Response, error) {
+	token, err := h.token(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if headers != nil {
-		req.Header = headers
+	baseURL := h.practiceBaseURL(info.practiceID)
+	if info.unscoped {
+		baseURL = strings.TrimSuffix(h.apiBaseURL(), "/")
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	req.Header.Add("User-Agent", userAgent)
+	reqURL := fmt.Sprintf("%s%s", baseURL, path)
 
-	if h.logger != nil {
-		h.logger.Info().
-			Str("method", method).
-			Str("url", reqURL).
-			Msg("athenahealth API request")
+	// Buffer the body so that it can be sent again if the request is retried.
+	var reqBody []byte
+	if body != nil {
+		reqBody, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
 	}
+	info.requestBytes = len(reqBody)
 
-	res, err := h.httpClient.Do(req)
-	if err != nil {
-		return res, err
+	res, attempts, err := h.send(ctx, method, reqURL, path, reqBody, headers, token, info)
+	info.retries = attempts - 1
+
+	// The cached token may have been revoked or expired early. Replace it and replay the
+	// request once before giving up.
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+
+		if h.logger != nil {
+			h.logger.Info().
+				Str("practiceID", info.practiceID).
+				Str("method", method).
+				Str("url", reqURL).
+				Msg("athenahealth API token rejected, refreshing")
+		}
+
+		token, err = h.refreshToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		res, attempts, err = h.send(ctx, method, reqURL, path, reqBody, headers, token, info)
+		info.retries += attempts
 	}
 
-	err = h.stats.Request(method, path)
 	if err != nil {
 		return res, err
 	}
 
 	responseError := res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices
-	if responseError {
-		err = h.stats.ResponseError()
-		if err != nil {
-			return res, err
-		}
-	} else {
-		err = h.stats.ResponseSuccess()
-		if err != nil {
-			return res, err
-		}
-	}
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
@@ -259,28 +361,14 @@ func (h *HTTPClient) request(ctx context.Context, method, path string, body io.R
 	res.Body.Close()
 
 	res.Body = ioutil.NopCloser(bytes.NewBuffer(resBody))
-
-	if h.logger != nil {
-		h.logger.Info().
-			Str("method", method).
-			Str("url", reqURL).
-			Int("statusCode", res.StatusCode).
-			Int("bodyLength", len(resBody)).
-			Msg("athenahealth API response")
-	}
+	info.responseBytes = len(resBody)
 
 	if responseError {
-		err := &APIError{}
-		if res.StatusCode == http.StatusNotFound {
-			err.Err = ErrNotFound
-		}
-
-		json.Unmarshal(resBody, err)
-
-		err.HTTPResponse = res
+		err := parseAPIError(res, resBody)
 
 		if h.logger != nil {
 			h.logger.Info().
+				Str("practiceID", info.practiceID).
 				Str("athenaError", err.AthenaError).
 				Str("athenaDetailedMessage", err.AthenaDetailedMessage).
 				Msg("athenahealth API error")
@@ -298,6 +386,314 @@ func (h *HTTPClient) request(ctx context.Context, method, path string, body io.R
 	return res, nil
 }
 
+// waitForRateLimit blocks until the rate limiter allows the request, the maximum rate limit wait
+// is exceeded or ctx is done. waited is the time the request already spent waiting on earlier
+// attempts, which counts towards the maximum. It returns the new total time spent waiting.
+func (h *HTTPClient) waitForRateLimit(ctx context.Context, waited time.Duration) (time.Duration, error) {
+	for {
+		retryAfter, err := h.rateLimiter.Allowed(ctx, h.preview)
+
+		if err == nil {
+			return waited, nil
+		}
+
+		if !errors.Is(err, ratelimiter.ErrRateExceeded) {
+			return waited, err
+		}
+
+		if h.maxRateLimitWait > 0 && waited+retryAfter > h.maxRateLimitWait {
+			return waited, &RateLimitWaitError{
+				Waited: waited,
+			}
+		}
+
+		err = sleepContext(ctx, retryAfter)
+		if err != nil {
+			return waited, err
+		}
+
+		waited += retryAfter
+	}
+}
+
+// Headers athenahealth uses to report the practice's plan limits and usage.
+const (
+	headerQPSAllotted   = "X-Plan-QPS-Allotted"
+	headerQPSCurrent    = "X-Plan-QPS-Current"
+	headerQuotaAllotted = "X-Plan-Quota-Allotted"
+	headerQuotaCurrent  = "X-Plan-Quota-Current"
+)
+
+// rateLimitFeedback passes the status code, Retry-After and quota headers of res to the rate
+// limiter if it is a RateLimitFeedbackReceiver.
+func (h *HTTPClient) rateLimitFeedback(ctx context.Context, res *http.Response) {
+	receiver, ok := h.rateLimiter.(RateLimitFeedbackReceiver)
+	if !ok {
+		return
+	}
+
+	headerInt := func(key string, missing int) int {
+		v, err := strconv.Atoi(res.Header.Get(key))
+		if err != nil {
+			return missing
+		}
+
+		return v
+	}
+
+	feedback := &ratelimiter.Feedback{
+		StatusCode:    res.StatusCode,
+		QPSAllotted:   headerInt(headerQPSAllotted, 0),
+		QPSCurrent:    headerInt(headerQPSCurrent, -1),
+		QuotaAllotted: headerInt(headerQuotaAllotted, 0),
+		QuotaCurrent:  headerInt(headerQuotaCurrent, -1),
+	}
+
+	if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+		feedback.RetryAfter = retryAfter
+	}
+
+	err := receiver.Feedback(ctx, h.preview, feedback)
+	if err != nil && h.logger != nil {
+		h.logger.Warn().
+			Err(err).
+			Int("statusCode", res.StatusCode).
+			Msg("athenahealth rate limit feedback failed")
+	}
+}
+
+// trackQuota counts a call to path with the quota tracker, if there is one, and warns when a soft
+// limit has been exceeded.
+func (h *HTTPClient) trackQuota(ctx context.Context, path string) error {
+	if h.quotaTracker == nil {
+		return nil
+	}
+
+	usage, err := h.quotaTracker.Track(ctx, stats.CleanPath(path))
+	if err != nil {
+		return err
+	}
+
+	if usage.SoftLimitExceeded && h.logger != nil {
+		h.logger.Warn().
+			Str("path", usage.Path).
+			Int64("day", usage.Day).
+			Int64("month", usage.Month).
+			Int64("totalDay", usage.TotalDay).
+			Int64("totalMonth", usage.TotalMonth).
+			Msg("athenahealth API quota soft limit exceeded")
+	}
+
+	return nil
+}
+
+// send makes an authenticated request, retrying it according to the retry policy. Every attempt
+// waits for the rate limiter, and the time waited is added to info. The maximum rate limit wait
+// applies to the total across attempts. It returns the number of
+// attempts made.
+func (h *HTTPClient) send(ctx context.Context, method, reqURL, path string, body []byte, headers http.Header, token string, info *requestInfo) (*http.Response, int, error) {
+	var res *http.Response
+	var err error
+
+	attempt := 1
+
+	for ; ; attempt++ {
+		info.rateLimitWait, err = h.waitForRateLimit(ctx, info.rateLimitWait)
+		if err != nil {
+			return nil, attempt, err
+		}
+
+		err = h.trackQuota(ctx, path)
+		if err != nil {
+			return nil, attempt, err
+		}
+
+		res, err = h.do(ctx, method, reqURL, path, body, headers, token)
+		if err == nil {
+			h.rateLimitFeedback(ctx, res)
+		}
+
+		if ctx.Err() != nil || !h.retryPolicy.shouldRetry(method, attempt, res, err) {
+			break
+		}
+
+		wait := h.retryPolicy.backoff(attempt, res)
+
+		// Don't start a wait that will outlive the request's deadline. Return the last
+		// response instead.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			break
+		}
+
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if h.logger != nil {
+			practiceID, _ := PracticeIDFromContext(ctx)
+
+			h.logger.Info().
+				Str("practiceID", practiceID).
+				Str("method", method).
+				Str("url", reqURL).
+				Int("attempt", attempt).
+				Dur("backoff", wait).
+				Msg("athenahealth API request retry")
+		}
+
+		err = sleepContext(ctx, wait)
+		if err != nil {
+			return nil, attempt, err
+		}
+	}
+
+	return res, attempt, err
+}
+
+// token returns the cached token, fetching a new one from the token provider if there isn't a
+// valid one. Concurrent fetches are deduplicated so that only one call to the token provider is in
+// flight at a time, across processes too if the token cacher is a TokenRefreshLocker.
+func (h *HTTPClient) token(ctx context.Context) (string, error) {
+	h.startTokenRefresher()
+
+	token, err := h.tokenCacher.Get(ctx)
+	if err == nil || !isTokenMiss(err) {
+		return token, err
+	}
+
+	if errors.Is(err, tokencacher.ErrTokenTampered) && h.logger != nil {
+		h.logger.Warn().
+			Err(err).
+			Msg("athenahealth cached token failed authentication, replacing it")
+	}
+
+	return h.tokenFlight.do(ctx, tokenFlightKey, func(ctx context.Context) (string, error) {
+		unlock, err := h.lockRefresh(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+
+		// Another caller may have cached a token since we checked.
+		token, err := h.tokenCacher.Get(ctx)
+		if err == nil || !isTokenMiss(err) {
+			return token, err
+		}
+
+		return h.provideToken(ctx)
+	})
+}
+
+// refreshToken replaces rejected, a token that athenahealth refused, with a new one from the
+// token provider.
+func (h *HTTPClient) refreshToken(ctx context.Context, rejected string) (string, error) {
+	token, err := h.tokenFlight.do(ctx, tokenFlightKey, h.replaceToken(rejected))
+	if err == nil && token == rejected {
+		// We joined a fetch that started before the token was rejected and returned it from the
+		// cache. Replace it ourselves.
+		token, err = h.tokenFlight.do(ctx, tokenFlightKey, h.replaceToken(rejected))
+	}
+
+	return token, err
+}
+
+// replaceToken returns a flight function that invalidates rejected and caches a new token, unless
+// another caller already replaced it.
+func (h *HTTPClient) replaceToken(rejected string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		unlock, err := h.lockRefresh(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+
+		// Another caller may have already replaced the rejected token.
+		token, err := h.tokenCacher.Get(ctx)
+		if err == nil && token != rejected {
+			return token, nil
+		}
+
+		if invalidator, ok := h.tokenCacher.(TokenInvalidator); ok {
+			err := invalidator.Invalidate(ctx)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		return h.provideToken(ctx)
+	}
+}
+
+// lockRefresh takes the token cacher's refresh lock if it is a TokenRefreshLocker.
+func (h *HTTPClient) lockRefresh(ctx context.Context) (func(), error) {
+	locker, ok := h.tokenCacher.(TokenRefreshLocker)
+	if !ok {
+		return func() {}, nil
+	}
+
+	return locker.LockRefresh(ctx)
+}
+
+// tokenFlightKey is the flight key of every token fetch, so that only one call to the token
+// provider is in flight at a time.
+const tokenFlightKey = "token"
+
+// isTokenMiss reports whether err from the token cacher means a new token should be fetched. A
+// token that fails authentication, e.g. a plaintext file left over from before encryption was
+// enabled, is replaced instead of failing every request.
+func isTokenMiss(err error) bool {
+	return errors.Is(err, tokencacher.ErrTokenNotExist) ||
+		errors.Is(err, tokencacher.ErrTokenExpired) ||
+		errors.Is(err, tokencacher.ErrTokenTampered)
+}
+
+func (h *HTTPClient) provideToken(ctx context.Context) (string, error) {
+	ctx, span := h.tracer.Start(ctx, "athenahealth token", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	token, expiresAt, err := h.tokenProvider.Provide(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+
+	// Remove 1 minute from the expiration time to create a buffer to see
+	// if it resolves intermittent 401s.
+	expiresAt = expiresAt.Add(-1 * time.Minute)
+
+	err = h.tokenCacher.Set(context.Background(), token, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	h.tokenFetched(token, time.Now(), expiresAt)
+
+	return token, nil
+}
+
+// do makes a single attempt at an authenticated request.
+func (h *HTTPClient) do(ctx context.Context, method, reqURL, path string, body []byte, headers http.Header, token string) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(withRequestPath(ctx, path), method, reqURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	if headers != nil {
+		req.Header = headers.Clone()
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Add("User-Agent", userAgent)
+
+	return h.roundTrip(req)
+}
+
 func (h *HTTPClient) WithLogger(logger *zerolog.Logger) *HTTPClient {
 	h.logger = logger
 
@@ -307,38 +703,95 @@ func (h *HTTPClient) WithLogger(logger *zerolog.Logger) *HTTPClient {
 func (h *HTTPClient) WithPreview(preview bool) *HTTPClient {
 	h.preview = preview
 	h.setBaseURL()
+	h.setTokenNamespace()
 
-	if _, ok := h.tokenProvider.(*tokenprovider.Default); ok {
-		h.tokenProvider = tokenprovider.NewDefault(h.httpClient, h.clientID, h.secret, preview)
+	if d, ok := h.tokenProvider.(*tokenprovider.Default); ok {
+		h.tokenProvider = tokenprovider.NewDefault(h.httpClient, h.clientID, h.secret, preview).
+			WithScopes(d.Scopes()...)
 	}
 
 	return h
 }
 
+// WithBaseURL sends requests to baseURL instead of PreviewBaseURL or ProdBaseURL, e.g. to use a
+// proxy or a fake API in tests. The practice ID is appended to it.
+func (h *HTTPClient) WithBaseURL(baseURL string) *HTTPClient {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	h.apiURL = baseURL
+	h.setBaseURL()
+
+	return h
+}
+
 func (h *HTTPClient) WithTokenProvider(provider TokenProvider) *HTTPClient {
 	h.tokenProvider = provider
 
 	return h
 }
 
+// WithTokenCacher caches tokens in cacher. If it is a TokenNamespacer, its namespace is set to the
+// client's ID and environment.
 func (h *HTTPClient) WithTokenCacher(cacher TokenCacher) *HTTPClient {
 	h.tokenCacher = cacher
+	h.setTokenNamespace()
 
 	return h
 }
 
+// setTokenNamespace sets the token cacher's namespace to the client's ID and environment, if it is
+// a TokenNamespacer.
+func (h *HTTPClient) setTokenNamespace() {
+	if n, ok := h.tokenCacher.(TokenNamespacer); ok {
+		n.SetNamespace(h.clientID, h.preview)
+	}
+}
+
 func (h *HTTPClient) WithRateLimiter(rateLimiter RateLimiter) *HTTPClient {
 	h.rateLimiter = rateLimiter
 
 	return h
 }
 
+// WithQuotaTracker counts every call, including retries, against the daily and monthly budgets of
+// tracker. Calls that would go over a hard limit aren't made and fail with the tracker's error.
+func (h *HTTPClient) WithQuotaTracker(tracker QuotaTracker) *HTTPClient {
+	h.quotaTracker = tracker
+
+	return h
+}
+
 func (h *HTTPClient) WithStats(stats Stats) *HTTPClient {
 	h.stats = stats
 
 	return h
 }
 
+// WithMaxRateLimitWait sets the maximum total time a request waits for the rate limiter before
+// failing with a *RateLimitWaitError. Zero, the default, waits until the request's context is done.
+func (h *HTTPClient) WithMaxRateLimitWait(d time.Duration) *HTTPClient {
+	h.maxRateLimitWait = d
+
+	return h
+}
+
+// WithMiddleware appends middleware to the chain every request goes through. See Middleware for
+// the order in which it runs.
+func (h *HTTPClient) WithMiddleware(middleware ...Middleware) *HTTPClient {
+	h.middleware = append(h.middleware, middleware...)
+
+	return h
+}
+
+// WithRetryPolicy enables retries of failed requests. A nil policy disables retries.
+func (h *HTTPClient) WithRetryPolicy(policy *RetryPolicy) *HTTPClient {
+	h.retryPolicy = policy
+
+	return h
+}
+
 func (h *HTTPClient) Get(ctx context.Context, path string, query url.Values, out interface{}) (*http.Response, error) {
 	if len(query) > 0 {
 		path = fmt.Sprintf("%s?%s", path, query.Encode())
@@ -1,6 +1,7 @@
 package athenahealth
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,11 +10,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis"
+	"github.com/asatish/go-athenahealth/athenahealth/quota"
 	"github.com/asatish/go-athenahealth/athenahealth/ratelimiter"
+	"github.com/asatish/go-athenahealth/athenahealth/stats"
+	"github.com/asatish/go-athenahealth/athenahealth/tokencacher"
+	"github.com/asatish/go-athenahealth/athenahealth/tokenprovider"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,6 +83,15 @@ func (t *testRateLimiter) Allowed(ctx context.Context, preview bool) (time.Durat
 	return 0, nil
 }
 
+type testFeedbackRateLimiter struct {
+	testRateLimiter
+	FeedbackFunc func(preview bool, feedback *ratelimiter.Feedback) error
+}
+
+func (t *testFeedbackRateLimiter) Feedback(ctx context.Context, preview bool, feedback *ratelimiter.Feedback) error {
+	return t.FeedbackFunc(preview, feedback)
+}
+
 type testStats struct {
 	RequestFunc         func(method, path string) error
 	ResponseSuccessFunc func() error
@@ -195,6 +215,27 @@ func TestHTTPClient_request_error(t *testing.T) {
 	assert.IsType(&APIError{}, err)
 }
 
+func TestHTTPClient_request_error_validation(t *testing.T) {
+	assert := assert.New(t)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "Additional fields are required.", "missingfields": ["dob"]}`))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	_, err := athenaClient.request(context.Background(), "POST", "/", nil, nil, nil)
+
+	assert.True(errors.Is(err, ErrBadRequest))
+
+	apiErr := &APIError{}
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal([]string{"dob"}, apiErr.MissingFields)
+	assert.Len(apiErr.ValidationErrors, 1)
+}
+
 func TestHTTPClient_rate_limit(t *testing.T) {
 	assert := assert.New(t)
 
@@ -226,6 +267,686 @@ func TestHTTPClient_rate_limit(t *testing.T) {
 	assert.True(called)
 }
 
+func TestHTTPClient_request_retry(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.Equal("foo", string(b))
+
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"msg":"Hello World!"}`))
+	}
+
+	requests := 0
+	responseErrors := 0
+	stats := &testStats{
+		RequestFunc: func(method, path string) error {
+			requests++
+			return nil
+		},
+		ResponseErrorFunc: func() error {
+			responseErrors++
+			return nil
+		},
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	athenaClient.WithStats(stats).WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		RetryableMethods:     []string{http.MethodPut},
+	})
+
+	var out map[string]string
+	res, err := athenaClient.request(context.Background(), "PUT", "/", strings.NewReader("foo"), nil, &out)
+
+	assert.NotNil(res)
+	assert.NoError(err)
+	assert.Equal("Hello World!", out["msg"])
+	assert.Equal(3, attempts)
+	assert.Equal(3, requests)
+	assert.Equal(2, responseErrors)
+}
+
+func TestHTTPClient_request_observe(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"msg": "Hello World!"}`))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	var observations []*stats.Observation
+
+	athenaClient.WithStats(stats.ObserverFunc(func(o *stats.Observation) error {
+		observations = append(observations, o)
+		return nil
+	})).WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          2,
+		BaseBackoff:          time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		RetryableMethods:     []string{http.MethodPut},
+	})
+
+	_, err := athenaClient.request(context.Background(), "PUT", "/patients/1?foo=bar", strings.NewReader("foo"), nil, nil)
+	assert.NoError(err)
+
+	assert.Len(observations, 1)
+
+	o := observations[0]
+	assert.Equal("PUT", o.Method)
+	assert.Equal("/patients/:id:", o.Path)
+	assert.Equal(http.StatusOK, o.StatusCode)
+	assert.Equal(1, o.Retries)
+	assert.Equal(3, o.RequestBytes)
+	assert.Equal(23, o.ResponseBytes)
+	assert.True(o.Duration > 0)
+	assert.NoError(o.Err)
+}
+
+func TestHTTPClient_request_retry_rate_limit(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	// Every other call to the rate limiter is rejected, so each attempt waits once.
+	allowedCalls := 0
+	rateLimiter := &testRateLimiter{}
+	rateLimiter.AllowedFunc = func(preview bool) (time.Duration, error) {
+		allowedCalls++
+		if allowedCalls%2 == 1 {
+			return time.Millisecond, ratelimiter.ErrRateExceeded
+		}
+
+		return 0, nil
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	var observations []*stats.Observation
+
+	athenaClient.WithRateLimiter(rateLimiter).WithStats(stats.ObserverFunc(func(o *stats.Observation) error {
+		observations = append(observations, o)
+		return nil
+	})).WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		RetryableMethods:     []string{http.MethodGet},
+	})
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	assert.Equal(3, attempts)
+	assert.Equal(6, allowedCalls)
+
+	assert.Len(observations, 1)
+	assert.Equal(3*time.Millisecond, observations[0].RateLimitWait)
+}
+
+func TestHTTPClient_request_retry_max_rate_limit_wait(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	// Every attempt waits 30ms for the rate limiter.
+	allowedCalls := 0
+	rateLimiter := &testRateLimiter{}
+	rateLimiter.AllowedFunc = func(preview bool) (time.Duration, error) {
+		allowedCalls++
+		if allowedCalls%2 == 1 {
+			return 30 * time.Millisecond, ratelimiter.ErrRateExceeded
+		}
+
+		return 0, nil
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	athenaClient.WithRateLimiter(rateLimiter).WithMaxRateLimitWait(50 * time.Millisecond).WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		RetryableMethods:     []string{http.MethodGet},
+	})
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+
+	// The maximum applies to the whole request, so the second attempt's wait goes over it.
+	waitErr := &RateLimitWaitError{}
+	assert.True(errors.As(err, &waitErr))
+	assert.Equal(30*time.Millisecond, waitErr.Waited)
+	assert.Equal(1, attempts)
+}
+
+func TestHTTPClient_request_retry_exhausted(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseBackoff = time.Millisecond
+	athenaClient.WithRetryPolicy(policy)
+
+	res, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+
+	assert.NotNil(res)
+	assert.IsType(&APIError{}, err)
+	assert.Equal(policy.MaxAttempts, attempts)
+}
+
+func TestHTTPClient_request_retry_non_idempotent(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseBackoff = time.Millisecond
+	athenaClient.WithRetryPolicy(policy)
+
+	_, err := athenaClient.request(context.Background(), "POST", "/", strings.NewReader("foo"), nil, nil)
+
+	assert.Error(err)
+	assert.Equal(1, attempts)
+}
+
+func TestHTTPClient_request_retry_deadline(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	athenaClient.WithRetryPolicy(DefaultRetryPolicy())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := athenaClient.request(ctx, "GET", "/", nil, nil, nil)
+
+	// The Retry-After wait would outlive the deadline, so the 429 is returned right away.
+	assert.Equal(http.StatusTooManyRequests, res.StatusCode)
+	assert.IsType(&APIError{}, err)
+	assert.Equal(1, attempts)
+}
+
+func TestHTTPClient_rate_limit_context(t *testing.T) {
+	assert := assert.New(t)
+
+	rateLimiter := &testRateLimiter{}
+	rateLimiter.AllowedFunc = func(preview bool) (time.Duration, error) {
+		return time.Hour, ratelimiter.ErrRateExceeded
+	}
+
+	called := false
+	h := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}
+
+	athenaClient, ts := testClient(h)
+	athenaClient.WithRateLimiter(rateLimiter)
+
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	res, err := athenaClient.request(ctx, "GET", "/", nil, nil, nil)
+
+	assert.Nil(res)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+	assert.True(time.Since(start) < time.Second)
+	assert.False(called)
+}
+
+func TestHTTPClient_rate_limit_max_wait(t *testing.T) {
+	assert := assert.New(t)
+
+	rateLimiter := &testRateLimiter{}
+	rateLimiter.AllowedFunc = func(preview bool) (time.Duration, error) {
+		return 20 * time.Millisecond, ratelimiter.ErrRateExceeded
+	}
+
+	athenaClient, ts := testClient(nil)
+	athenaClient.WithRateLimiter(rateLimiter).WithMaxRateLimitWait(50 * time.Millisecond)
+
+	defer ts.Close()
+
+	res, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+
+	assert.Nil(res)
+	assert.True(errors.Is(err, ErrRateLimitWaitExceeded))
+
+	waitErr := &RateLimitWaitError{}
+	assert.True(errors.As(err, &waitErr))
+	assert.Equal(40*time.Millisecond, waitErr.Waited)
+}
+
+func TestHTTPClient_rate_limit_feedback(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("X-Plan-QPS-Allotted", "10")
+		w.Header().Set("X-Plan-QPS-Current", "4")
+
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Write([]byte(`{}`))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	var feedback []*ratelimiter.Feedback
+
+	athenaClient.WithRateLimiter(&testFeedbackRateLimiter{
+		FeedbackFunc: func(preview bool, f *ratelimiter.Feedback) error {
+			feedback = append(feedback, f)
+			return nil
+		},
+	}).WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          2,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+		RetryableMethods:     []string{http.MethodGet},
+		RespectRetryAfter:    true,
+	})
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	assert.Len(feedback, 2)
+	assert.Equal(&ratelimiter.Feedback{
+		StatusCode:   http.StatusTooManyRequests,
+		QPSAllotted:  10,
+		QPSCurrent:   4,
+		QuotaCurrent: -1,
+	}, feedback[0])
+	assert.Equal(http.StatusOK, feedback[1].StatusCode)
+}
+
+func TestHTTPClient_request_quota(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{}`))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	tracker := quota.NewMemory(&quota.Options{
+		Endpoints: map[string]quota.Budget{
+			"/patients/:id:": {
+				Soft: quota.Limit{Daily: 1},
+				Hard: quota.Limit{Daily: 2},
+			},
+		},
+	})
+
+	athenaClient.WithQuotaTracker(tracker).WithLogger(&logger)
+
+	_, err := athenaClient.request(context.Background(), "GET", "/patients/1", nil, nil, nil)
+	assert.NoError(err)
+	assert.NotContains(buf.String(), "soft limit exceeded")
+
+	_, err = athenaClient.request(context.Background(), "GET", "/patients/2", nil, nil, nil)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "athenahealth API quota soft limit exceeded")
+
+	_, err = athenaClient.request(context.Background(), "GET", "/patients/3", nil, nil, nil)
+	assert.True(errors.Is(err, quota.ErrQuotaExceeded))
+	assert.Equal(2, calls)
+
+	usage, err := tracker.Usage(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.Equal(int64(2), usage.Day)
+}
+
+func TestHTTPClient_WithMaxRateLimitWait(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient := NewHTTPClient(&http.Client{}, "", "", "")
+	athenaClient.WithMaxRateLimitWait(time.Second)
+
+	assert.Equal(time.Second, athenaClient.maxRateLimitWait)
+}
+
+type testRefreshingTokenProvider struct {
+	calls int
+}
+
+func (t *testRefreshingTokenProvider) Provide(ctx context.Context) (string, time.Time, error) {
+	t.calls++
+
+	return fmt.Sprintf("token-%d", t.calls), time.Now().Add(time.Hour), nil
+}
+
+func TestHTTPClient_request_unauthorized_refresh(t *testing.T) {
+	assert := assert.New(t)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.Equal("foo", string(b))
+
+		w.Write([]byte(`{"msg":"Hello World!"}`))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	tokenProvider := &testRefreshingTokenProvider{}
+	tokenCacher := tokencacher.NewDefault()
+	athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokenCacher)
+
+	var out map[string]string
+	res, err := athenaClient.request(context.Background(), "POST", "/", strings.NewReader("foo"), nil, &out)
+
+	assert.NotNil(res)
+	assert.NoError(err)
+	assert.Equal("Hello World!", out["msg"])
+	assert.Equal(2, tokenProvider.calls)
+
+	token, err := tokenCacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("token-2", token)
+}
+
+func TestHTTPClient_request_unauthorized(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	tokenProvider := &testRefreshingTokenProvider{}
+	athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokencacher.NewDefault())
+
+	res, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+
+	assert.Equal(http.StatusUnauthorized, res.StatusCode)
+	assert.IsType(&APIError{}, err)
+	assert.Equal(2, attempts)
+	assert.Equal(2, tokenProvider.calls)
+}
+
+type testSlowTokenProvider struct {
+	calls int32
+}
+
+func (t *testSlowTokenProvider) Provide(ctx context.Context) (string, time.Time, error) {
+	atomic.AddInt32(&t.calls, 1)
+	time.Sleep(50 * time.Millisecond)
+
+	return testToken, time.Now().Add(time.Hour), nil
+}
+
+func TestHTTPClient_request_concurrent_token(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	tokenProvider := &testSlowTokenProvider{}
+	athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokencacher.NewDefault())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+			assert.NoError(err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&tokenProvider.calls))
+}
+
+func TestHTTPClient_request_concurrent_token_canceled(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	tokenProvider := &testSlowTokenProvider{}
+	athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokencacher.NewDefault())
+
+	// The first request starts the token fetch and gives up before it completes.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	firstErr := make(chan error)
+	go func() {
+		_, err := athenaClient.request(ctx, "GET", "/", nil, nil, nil)
+		firstErr <- err
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	assert.True(errors.Is(<-firstErr, context.DeadlineExceeded))
+	assert.Equal(int32(1), atomic.LoadInt32(&tokenProvider.calls))
+}
+
+func TestHTTPClient_refreshToken_shares_flight(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	tokenProvider := &testSlowTokenProvider{}
+	athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokencacher.NewDefault())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			_, err := athenaClient.token(context.Background())
+			assert.NoError(err)
+		}()
+		go func() {
+			defer wg.Done()
+
+			_, err := athenaClient.refreshToken(context.Background(), "rejected")
+			assert.NoError(err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&tokenProvider.calls))
+}
+
+func TestHTTPClient_request_token_refresh_lock(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "token")
+	tokenProvider := &testSlowTokenProvider{}
+
+	var wg sync.WaitGroup
+
+	// Separate clients with separate Files act like separate processes sharing the token file.
+	for i := 0; i < 5; i++ {
+		athenaClient, ts := testClient(nil)
+		defer ts.Close()
+
+		athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokencacher.NewFile(path))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+			assert.NoError(err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&tokenProvider.calls))
+}
+
+func BenchmarkHTTPClient_request_concurrent(b *testing.B) {
+	const callers = 100
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	athenaClient.WithTokenCacher(tokencacher.NewDefault())
+
+	requests := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range requests {
+				_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+				if err != nil {
+					b.Error(err)
+				}
+			}
+		}()
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		requests <- struct{}{}
+	}
+
+	close(requests)
+	wg.Wait()
+}
+
+func TestHTTPClient_request_encrypted_token_file_upgrade(t *testing.T) {
+	assert := assert.New(t)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("Bearer token-1", r.Header.Get("Authorization"))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	// A plaintext token file written before encryption was enabled.
+	path := filepath.Join(t.TempDir(), "token")
+	assert.NoError(tokencacher.NewFile(path).Set(context.Background(), "plaintext", time.Now().Add(time.Hour)))
+
+	key := bytes.Repeat([]byte{1}, 32)
+	tokenCacher := tokencacher.NewFile(path).WithEncryption(key)
+
+	_, err := tokenCacher.Get(context.Background())
+	assert.True(errors.Is(err, tokencacher.ErrTokenTampered))
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	tokenProvider := &testRefreshingTokenProvider{}
+	athenaClient.WithTokenProvider(tokenProvider).WithTokenCacher(tokenCacher).WithLogger(&logger)
+
+	_, err = athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "athenahealth cached token failed authentication")
+
+	// The plaintext token was replaced with an encrypted one, which later requests reuse.
+	_, err = athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+	assert.Equal(1, tokenProvider.calls)
+
+	token, err := tokenCacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("token-1", token)
+}
+
 func TestHTTPClient_WithPreview(t *testing.T) {
 	assert := assert.New(t)
 
@@ -234,6 +955,25 @@ func TestHTTPClient_WithPreview(t *testing.T) {
 	athenaClient.WithPreview(false)
 
 	assert.False(athenaClient.preview)
+
+	athenaClient.WithTokenProvider(tokenprovider.NewDefault(&http.Client{}, "", "", false).WithScopes("system/Patient.read"))
+	athenaClient.WithPreview(true)
+
+	assert.Equal([]string{"system/Patient.read"}, athenaClient.tokenProvider.(*tokenprovider.Default).Scopes())
+}
+
+func TestHTTPClient_WithBaseURL(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient := NewHTTPClient(&http.Client{}, "123", "", "").
+		WithBaseURL("http://localhost:8080/athena")
+
+	assert.Equal("http://localhost:8080/athena/123", athenaClient.baseURL)
+
+	// The override survives switching environments.
+	athenaClient.WithPreview(true)
+
+	assert.Equal("http://localhost:8080/athena/123", athenaClient.baseURL)
 }
 
 func TestHTTPClient_WithTokenProvider(t *testing.T) {
@@ -258,6 +998,37 @@ func TestHTTPClient_WithTokenCacher(t *testing.T) {
 	assert.Equal(tokenCacher, athenaClient.tokenCacher)
 }
 
+func TestHTTPClient_WithTokenCacher_namespace(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+
+	// Clients for different environments share the Redis server without sharing a token.
+	prod := NewHTTPClient(&http.Client{}, "", "client-1", "").
+		WithTokenCacher(tokencacher.NewRedis(client, ""))
+	preview := NewHTTPClient(&http.Client{}, "", "client-1", "").
+		WithTokenCacher(tokencacher.NewRedis(client, "")).
+		WithPreview(true)
+
+	assert.NoError(prod.tokenCacher.Set(context.Background(), "prod-token", time.Now().Add(time.Minute)))
+	assert.NoError(preview.tokenCacher.Set(context.Background(), "preview-token", time.Now().Add(time.Minute)))
+
+	assert.True(s.Exists("athena_token:prod:client-1"))
+	assert.True(s.Exists("athena_token:preview:client-1"))
+
+	token, err := prod.tokenCacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("prod-token", token)
+}
+
 func TestHTTPClient_WithRateLimiter(t *testing.T) {
 	assert := assert.New(t)
 
@@ -269,6 +1040,17 @@ func TestHTTPClient_WithRateLimiter(t *testing.T) {
 	assert.Equal(rateLimiter, athenaClient.rateLimiter)
 }
 
+func TestHTTPClient_WithRetryPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient := NewHTTPClient(&http.Client{}, "", "", "")
+
+	policy := DefaultRetryPolicy()
+	athenaClient.WithRetryPolicy(policy)
+
+	assert.Equal(policy, athenaClient.retryPolicy)
+}
+
 func TestHTTPClient_WithStats(t *testing.T) {
 	assert := assert.New(t)
 
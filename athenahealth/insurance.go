@@ -126,3 +126,60 @@ func (h *HTTPClient) ListPatientInsurancePackages(ctx context.Context, opts *Lis
 		Pagination:        makePaginationResult(out.Next, out.Previous, out.TotalCount),
 	}, nil
 }
+
+// InsurancePackageIterator pages through a list of insurance packages. Call Next to advance and
+// Value to get the current insurance package.
+type InsurancePackageIterator struct {
+	*iterator
+}
+
+// Value returns the current insurance package.
+func (i *InsurancePackageIterator) Value() *InsurancePackage {
+	v, _ := i.current.(*InsurancePackage)
+	return v
+}
+
+// NewPatientInsurancePackageIterator returns an iterator over a patient's insurance packages,
+// fetching pages from ListPatientInsurancePackages as needed.
+func NewPatientInsurancePackageIterator(client Client, opts *ListPatientInsurancePackagesOptions, iterOpts *IteratorOptions) *InsurancePackageIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListPatientInsurancePackagesOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListPatientInsurancePackages(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.InsurancePackages))
+		for i, item := range res.InsurancePackages {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+
+	return &InsurancePackageIterator{newIterator(fetch, start, iterOpts)}
+}
+
+// ListAllPatientInsurancePackages returns a patient's insurance packages, fetching pages until
+// there are no more, iterOpts.MaxItems is reached or ctx is done. On error, the results fetched so
+// far are returned with it.
+func (h *HTTPClient) ListAllPatientInsurancePackages(ctx context.Context, opts *ListPatientInsurancePackagesOptions, iterOpts *IteratorOptions) ([]*InsurancePackage, error) {
+	it := NewPatientInsurancePackageIterator(h, opts, iterOpts)
+
+	var out []*InsurancePackage
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
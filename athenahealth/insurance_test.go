@@ -69,3 +69,23 @@ func TestHTTPClient_ListPatientInsurancePackages(t *testing.T) {
 	assert.Equal(res.Pagination.TotalCount, 2)
 	assert.NoError(err)
 }
+
+func TestHTTPClient_ListAllPatientInsurancePackages(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("insurances", 15, func(i int) interface{} {
+		return map[string]string{"insuranceid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	opts := &ListPatientInsurancePackagesOptions{
+		PatientID: "1",
+	}
+
+	packages, err := athenaClient.ListAllPatientInsurancePackages(context.Background(), opts, &IteratorOptions{PageSize: 5})
+
+	assert.NoError(err)
+	assert.Len(packages, 15)
+}
@@ -0,0 +1,112 @@
+package athenahealth
+
+import (
+	"context"
+)
+
+// IteratorOptions controls how list iterators and ListAll helpers page through results.
+type IteratorOptions struct {
+	// PageSize is the number of results requested per page. Zero uses the limit in the list
+	// options' Pagination, or athenahealth's default if that is not set either.
+	PageSize int
+
+	// MaxItems stops iteration once this many results have been returned. Zero means no cap.
+	MaxItems int
+}
+
+// pageFetcher fetches a single page of results.
+type pageFetcher func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error)
+
+// iterator walks the pages returned by a pageFetcher, following the offsets from
+// makePaginationResult until there are no more pages. It backs the typed iterators for every
+// List* endpoint.
+type iterator struct {
+	fetch pageFetcher
+
+	pageSize int
+	maxItems int
+	offset   int
+
+	page    []interface{}
+	current interface{}
+	count   int
+
+	done bool
+	err  error
+}
+
+func newIterator(fetch pageFetcher, start *PaginationOptions, opts *IteratorOptions) *iterator {
+	it := &iterator{
+		fetch: fetch,
+	}
+
+	if start != nil {
+		it.pageSize = start.Limit
+		it.offset = start.Offset
+	}
+
+	if opts != nil {
+		if opts.PageSize > 0 {
+			it.pageSize = opts.PageSize
+		}
+
+		it.maxItems = opts.MaxItems
+	}
+
+	return it
+}
+
+// Next advances to the next result, fetching the next page when the current one is exhausted. It
+// returns false when there are no more results, the item cap is reached, ctx is done or a request
+// fails. Check Err after Next returns false.
+func (it *iterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.maxItems > 0 && it.count >= it.maxItems {
+		return false
+	}
+
+	for len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		err := ctx.Err()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		items, pagination, err := it.fetch(ctx, &PaginationOptions{
+			Limit:  it.pageSize,
+			Offset: it.offset,
+		})
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = items
+
+		// The last page has no next URL. Guard against an offset that doesn't move forward so
+		// a misbehaving response can't loop forever.
+		if len(items) == 0 || pagination == nil || pagination.NextOffset <= it.offset {
+			it.done = true
+		} else {
+			it.offset = pagination.NextOffset
+		}
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	it.count++
+
+	return true
+}
+
+// Err returns the error, if any, that stopped iteration.
+func (it *iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,175 @@
+package athenahealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPagedHandler serves total items under key, paging them with the limit and offset query
+// parameters like athenahealth does.
+func testPagedHandler(key string, total int, item func(i int) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit == 0 {
+			limit = 10
+		}
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		items := []interface{}{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			items = append(items, item(i))
+		}
+
+		res := map[string]interface{}{
+			key:          items,
+			"totalcount": total,
+		}
+
+		if offset+limit < total {
+			res["next"] = fmt.Sprintf("%s?limit=%d&offset=%d", r.URL.Path, limit, offset+limit)
+		}
+
+		if offset > 0 {
+			res["previous"] = fmt.Sprintf("%s?limit=%d&offset=%d", r.URL.Path, limit, offset-limit)
+		}
+
+		b, _ := json.Marshal(res)
+		w.Write(b)
+	}
+}
+
+func testPageFetcher(total int, calls *[]PaginationOptions) pageFetcher {
+	return func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		*calls = append(*calls, *pagination)
+
+		var items []interface{}
+		for i := pagination.Offset; i < pagination.Offset+pagination.Limit && i < total; i++ {
+			items = append(items, i)
+		}
+
+		next := ""
+		if pagination.Offset+pagination.Limit < total {
+			next = fmt.Sprintf("/?offset=%d", pagination.Offset+pagination.Limit)
+		}
+
+		return items, makePaginationResult(next, "", total), nil
+	}
+}
+
+func TestIterator_Next(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []PaginationOptions
+	it := newIterator(testPageFetcher(25, &calls), nil, &IteratorOptions{PageSize: 10})
+
+	var values []int
+	for it.Next(context.Background()) {
+		values = append(values, it.current.(int))
+	}
+
+	assert.NoError(it.Err())
+	assert.Len(values, 25)
+	assert.Equal(0, values[0])
+	assert.Equal(24, values[24])
+	assert.Equal([]PaginationOptions{
+		{Limit: 10, Offset: 0},
+		{Limit: 10, Offset: 10},
+		{Limit: 10, Offset: 20},
+	}, calls)
+
+	// Exhausted iterators stay exhausted.
+	assert.False(it.Next(context.Background()))
+}
+
+func TestIterator_Next_start(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []PaginationOptions
+	it := newIterator(testPageFetcher(25, &calls), &PaginationOptions{Limit: 5, Offset: 15}, nil)
+
+	count := 0
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	assert.NoError(it.Err())
+	assert.Equal(10, count)
+	assert.Equal(PaginationOptions{Limit: 5, Offset: 15}, calls[0])
+}
+
+func TestIterator_Next_maxItems(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []PaginationOptions
+	it := newIterator(testPageFetcher(100, &calls), nil, &IteratorOptions{PageSize: 10, MaxItems: 15})
+
+	count := 0
+	for it.Next(context.Background()) {
+		count++
+	}
+
+	assert.NoError(it.Err())
+	assert.Equal(15, count)
+	assert.Len(calls, 2)
+}
+
+func TestIterator_Next_context(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []PaginationOptions
+	it := newIterator(testPageFetcher(100, &calls), nil, &IteratorOptions{PageSize: 10})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	for it.Next(ctx) {
+		count++
+
+		if count == 10 {
+			cancel()
+		}
+	}
+
+	assert.True(errors.Is(it.Err(), context.Canceled))
+	assert.Equal(10, count)
+	assert.Len(calls, 1)
+}
+
+func TestIterator_Next_error(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("boom")
+
+	it := newIterator(func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		return nil, nil, expectedErr
+	}, nil, nil)
+
+	assert.False(it.Next(context.Background()))
+	assert.Equal(expectedErr, it.Err())
+}
+
+func TestIterator_Next_stuckOffset(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	it := newIterator(func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		calls++
+
+		return []interface{}{1}, &PaginationResult{NextOffset: 0, TotalCount: 100}, nil
+	}, nil, nil)
+
+	for it.Next(context.Background()) {
+	}
+
+	assert.NoError(it.Err())
+	assert.Equal(1, calls)
+}
@@ -0,0 +1,140 @@
+package athenahealth
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/rs/zerolog"
+)
+
+// RoundTrip sends a single authenticated request to athenahealth and returns its response.
+type RoundTrip func(*http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTrip to inspect or modify requests and responses, e.g. to add headers,
+// trace, audit, cache or inject faults.
+//
+// Middleware added with WithMiddleware runs in the order it was added: the first middleware is
+// the outermost. The client's logging and stats middleware always run after all of them, right
+// before the request is sent, so they observe exactly what goes over the wire. Middleware runs
+// once per attempt when requests are retried.
+type Middleware func(next RoundTrip) RoundTrip
+
+type requestPathKey struct{}
+
+// withRequestPath stores the request's path relative to the practice's base URL in ctx.
+func withRequestPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, requestPathKey{}, path)
+}
+
+// requestPath returns the request's path relative to the practice's base URL.
+func requestPath(req *http.Request) string {
+	if path, ok := req.Context().Value(requestPathKey{}).(string); ok {
+		return path
+	}
+
+	return req.URL.RequestURI()
+}
+
+// roundTrip sends req through the middleware chain.
+func (h *HTTPClient) roundTrip(req *http.Request) (*http.Response, error) {
+	next := RoundTrip(h.httpClient.Do)
+
+	next = statsMiddleware(h.stats)(next)
+
+	if h.logger != nil {
+		next = loggingMiddleware(h.logger)(next)
+	}
+
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		next = h.middleware[i](next)
+	}
+
+	return next(req)
+}
+
+// statsMiddleware reports every request and its outcome to stats. Requests are tagged with their
+// practice if stats is a PracticeStats.
+func statsMiddleware(stats Stats) Middleware {
+	practiceStats, _ := stats.(PracticeStats)
+
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			var err error
+
+			if practiceStats != nil {
+				practiceID, _ := PracticeIDFromContext(req.Context())
+				err = practiceStats.PracticeRequest(practiceID, req.Method, requestPath(req))
+			} else {
+				err = stats.Request(req.Method, requestPath(req))
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := next(req)
+			if err != nil {
+				statsErr := stats.ResponseError()
+				if statsErr != nil {
+					return res, statsErr
+				}
+
+				return res, err
+			}
+
+			responseError := res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices
+			if responseError {
+				err = stats.ResponseError()
+			} else {
+				err = stats.ResponseSuccess()
+			}
+
+			if err != nil {
+				return res, err
+			}
+
+			return res, nil
+		}
+	}
+}
+
+// loggingMiddleware logs every request and response.
+func loggingMiddleware(logger *zerolog.Logger) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			reqURL := req.URL.String()
+
+			practiceID, _ := PracticeIDFromContext(req.Context())
+
+			logger.Info().
+				Str("practiceID", practiceID).
+				Str("method", req.Method).
+				Str("url", reqURL).
+				Msg("athenahealth API request")
+
+			res, err := next(req)
+			if err != nil {
+				return res, err
+			}
+
+			resBody, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				return res, err
+			}
+			res.Body.Close()
+
+			res.Body = ioutil.NopCloser(bytes.NewBuffer(resBody))
+
+			logger.Info().
+				Str("practiceID", practiceID).
+				Str("method", req.Method).
+				Str("url", reqURL).
+				Int("statusCode", res.StatusCode).
+				Int("bodyLength", len(resBody)).
+				Msg("athenahealth API response")
+
+			return res, nil
+		}
+	}
+}
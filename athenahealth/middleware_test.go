@@ -0,0 +1,198 @@
+package athenahealth
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name+":before")
+			req.Header.Add("X-Middleware", name)
+
+			res, err := next(req)
+
+			*calls = append(*calls, name+":after")
+
+			return res, err
+		}
+	}
+}
+
+func TestHTTPClient_WithMiddleware_order(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []string
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "server")
+
+		// Headers added by middleware are sent in the order the middleware was added.
+		assert.Equal([]string{"first", "second"}, r.Header.Values("X-Middleware"))
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	athenaClient.WithStats(&testStats{
+		RequestFunc: func(method, path string) error {
+			calls = append(calls, "stats:request")
+			return nil
+		},
+		ResponseSuccessFunc: func() error {
+			calls = append(calls, "stats:response")
+			return nil
+		},
+	})
+
+	athenaClient.
+		WithMiddleware(testRecordingMiddleware("first", &calls)).
+		WithMiddleware(testRecordingMiddleware("second", &calls))
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	// The first middleware added is the outermost and the built-in stats middleware is the
+	// innermost.
+	assert.Equal([]string{
+		"first:before",
+		"second:before",
+		"stats:request",
+		"server",
+		"stats:response",
+		"second:after",
+		"first:after",
+	}, calls)
+}
+
+func TestHTTPClient_WithMiddleware_shortCircuit(t *testing.T) {
+	assert := assert.New(t)
+
+	called := false
+	h := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	requests := 0
+	athenaClient.WithStats(&testStats{
+		RequestFunc: func(method, path string) error {
+			requests++
+			return nil
+		},
+	})
+
+	athenaClient.WithMiddleware(func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"msg":"cached"}`)),
+				Request:    req,
+			}, nil
+		}
+	})
+
+	var out map[string]string
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, &out)
+
+	assert.NoError(err)
+	assert.Equal("cached", out["msg"])
+	assert.False(called)
+	assert.Zero(requests)
+}
+
+func TestHTTPClient_WithMiddleware_retry(t *testing.T) {
+	assert := assert.New(t)
+
+	attempts := 0
+	h := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseBackoff = time.Millisecond
+
+	var calls []string
+	athenaClient.
+		WithRetryPolicy(policy).
+		WithMiddleware(testRecordingMiddleware("mw", &calls))
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+
+	assert.NoError(err)
+	assert.Equal([]string{"mw:before", "mw:after", "mw:before", "mw:after"}, calls)
+}
+
+func Test_statsMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	var path string
+	success := false
+	stats := &testStats{
+		RequestFunc: func(method, p string) error {
+			path = p
+			return nil
+		},
+		ResponseSuccessFunc: func() error {
+			success = true
+			return nil
+		},
+	}
+
+	next := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	req, _ := http.NewRequestWithContext(withRequestPath(context.Background(), "/patients/1"), "GET", "https://example.com/v1/1/patients/1", nil)
+
+	_, err := statsMiddleware(stats)(next)(req)
+
+	assert.NoError(err)
+	assert.Equal("/patients/1", path)
+	assert.True(success)
+}
+
+func Test_loggingMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	next := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("hello")),
+		}, nil
+	}
+
+	req, _ := http.NewRequest("GET", "https://example.com/v1/1/patients/1", nil)
+
+	res, err := loggingMiddleware(&logger)(next)(req)
+	assert.NoError(err)
+
+	// The body can still be read after it was logged.
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal("hello", string(b))
+
+	assert.Contains(buf.String(), `"message":"athenahealth API request"`)
+	assert.Contains(buf.String(), `"bodyLength":5`)
+}
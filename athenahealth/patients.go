@@ -278,6 +278,98 @@ func (h *HTTPClient) ListPatients(ctx context.Context, opts *ListPatientsOptions
 	}, nil
 }
 
+// PatientIterator pages through a list of patients. Call Next to advance and Value to get the
+// current patient.
+type PatientIterator struct {
+	*iterator
+}
+
+// Value returns the current patient.
+func (i *PatientIterator) Value() *Patient {
+	v, _ := i.current.(*Patient)
+	return v
+}
+
+// patientPageFetcher returns a pageFetcher for ListPatients with opts.
+func patientPageFetcher(client Client, opts *ListPatientsOptions) pageFetcher {
+	return func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListPatientsOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListPatients(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.Patients))
+		for i, item := range res.Patients {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+}
+
+// NewPatientIterator returns an iterator over patients, fetching pages from ListPatients as needed.
+func NewPatientIterator(client Client, opts *ListPatientsOptions, iterOpts *IteratorOptions) *PatientIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	return &PatientIterator{newIterator(patientPageFetcher(client, opts), start, iterOpts)}
+}
+
+// ListAllPatients returns patients, fetching pages until there are no more, iterOpts.MaxItems is
+// reached or ctx is done. On error, the results fetched so far are returned with it.
+func (h *HTTPClient) ListAllPatients(ctx context.Context, opts *ListPatientsOptions, iterOpts *IteratorOptions) ([]*Patient, error) {
+	it := NewPatientIterator(h, opts, iterOpts)
+
+	var out []*Patient
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
+
+// FetchAllPatientsResult is the result of FetchAllPatients.
+type FetchAllPatientsResult struct {
+	Patients []*Patient
+
+	// Warnings is non-empty if the total count changed while pages were being fetched.
+	Warnings []*PaginationConsistencyWarning
+}
+
+// FetchAllPatients returns all patients matching opts. After the first page it fetches the remaining pages
+// concurrently, bounded by fetchOpts.Workers, and returns the results in order.
+func (h *HTTPClient) FetchAllPatients(ctx context.Context, opts *ListPatientsOptions, fetchOpts *FetchAllPagesOptions) (*FetchAllPatientsResult, error) {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	items, warnings, err := fetchAllPages(ctx, patientPageFetcher(h, opts), start, fetchOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logConsistencyWarnings("/patients", warnings)
+
+	patients := make([]*Patient, len(items))
+	for i, item := range items {
+		patients[i] = item.(*Patient)
+	}
+
+	return &FetchAllPatientsResult{
+		Patients: patients,
+		Warnings: warnings,
+	}, nil
+}
+
 type GetPatientPhotoOptions struct {
 	JPEGOutput bool
 }
@@ -548,6 +640,51 @@ func (h *HTTPClient) ListPatientsMatchingCustomField(ctx context.Context, opts *
 	}, nil
 }
 
+// NewPatientsMatchingCustomFieldIterator returns an iterator over patients matching a custom field,
+// fetching pages from ListPatientsMatchingCustomField as needed.
+func NewPatientsMatchingCustomFieldIterator(client Client, opts *ListPatientsMatchingCustomFieldOptions, iterOpts *IteratorOptions) *PatientIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListPatientsMatchingCustomFieldOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListPatientsMatchingCustomField(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.Patients))
+		for i, item := range res.Patients {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+
+	return &PatientIterator{newIterator(fetch, start, iterOpts)}
+}
+
+// ListAllPatientsMatchingCustomField returns patients matching a custom field, fetching pages until
+// there are no more, iterOpts.MaxItems is reached or ctx is done. On error, the results fetched so
+// far are returned with it.
+func (h *HTTPClient) ListAllPatientsMatchingCustomField(ctx context.Context, opts *ListPatientsMatchingCustomFieldOptions, iterOpts *IteratorOptions) ([]*Patient, error) {
+	it := NewPatientsMatchingCustomFieldIterator(h, opts, iterOpts)
+
+	var out []*Patient
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
+
 type CreatePatientOptions struct {
 	Address1              string
 	Address2              string
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -337,3 +338,79 @@ func TestHTTPClient_CreatePatient(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal("100", actualPatientID)
 }
+
+func TestHTTPClient_ListAllPatients(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("patients", 25, func(i int) interface{} {
+		return map[string]string{"patientid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	patients, err := athenaClient.ListAllPatients(context.Background(), &ListPatientsOptions{}, &IteratorOptions{PageSize: 10})
+
+	assert.NoError(err)
+	assert.Len(patients, 25)
+	assert.Equal("24", patients[24].PatientID)
+}
+
+func TestHTTPClient_ListAllPatientsMatchingCustomField(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("patients", 25, func(i int) interface{} {
+		return map[string]string{"patientid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	opts := &ListPatientsMatchingCustomFieldOptions{
+		CustomFieldID:    "1",
+		CustomFieldValue: "foo",
+	}
+
+	patients, err := athenaClient.ListAllPatientsMatchingCustomField(context.Background(), opts, &IteratorOptions{PageSize: 10, MaxItems: 12})
+
+	assert.NoError(err)
+	assert.Len(patients, 12)
+}
+
+func TestHTTPClient_FetchAllPatients(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("patients", 95, func(i int) interface{} {
+		return map[string]string{"patientid": strconv.Itoa(i)}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	var lock sync.Mutex
+	allowed := 0
+	athenaClient.WithRateLimiter(&testRateLimiter{
+		AllowedFunc: func(preview bool) (time.Duration, error) {
+			lock.Lock()
+			allowed++
+			lock.Unlock()
+
+			return 0, nil
+		},
+	})
+
+	res, err := athenaClient.FetchAllPatients(context.Background(), &ListPatientsOptions{}, &FetchAllPagesOptions{
+		PageSize: 10,
+		Workers:  3,
+	})
+
+	assert.NoError(err)
+	assert.Empty(res.Warnings)
+	assert.Len(res.Patients, 95)
+	for i, p := range res.Patients {
+		assert.Equal(strconv.Itoa(i), p.PatientID)
+	}
+
+	// Every page goes through the rate limiter.
+	assert.Equal(10, allowed)
+}
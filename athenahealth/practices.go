@@ -0,0 +1,103 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// allPracticesID is the practice ID athenahealth uses for requests about every practice the
+// credentials can reach.
+const allPracticesID = "1"
+
+// Practice describes a practice, as returned by GetPracticeInfo and ListPractices.
+type Practice struct {
+	PracticeID            string     `json:"practiceid"`
+	Name                  string     `json:"name"`
+	GoLiveDate            string     `json:"golivedate"`
+	ExperienceMode        string     `json:"experiencemode"`
+	HasClinicals          BoolString `json:"hasclinicals"`
+	HasCollector          BoolString `json:"hascollector"`
+	HasCommunicator       BoolString `json:"hascommunicator"`
+	HasUserSecurity       BoolString `json:"hasusersecurity"`
+	IsCoordinatorSender   BoolString `json:"iscoordinatorsender"`
+	IsCoordinatorReceiver BoolString `json:"iscoordinatorreceiver"`
+}
+
+type practiceInfoResponse struct {
+	Practices []*Practice `json:"practiceinfo"`
+
+	PaginationResponse
+}
+
+// GetPracticeInfo - Information about a practice. The client's practice is used if practiceID is
+// empty.
+// GET /v1/{practiceid}/practiceinfo
+// https://developer.athenahealth.com/docs/read/administrative/Practice_Info#section-0
+func (h *HTTPClient) GetPracticeInfo(ctx context.Context, practiceID string) (*Practice, error) {
+	if len(practiceID) == 0 {
+		practiceID = h.requestPracticeID(ctx)
+	}
+
+	out := &practiceInfoResponse{}
+
+	_, err := h.unscopedRequest(ctx, practiceID, "GET", fmt.Sprintf("/%s/practiceinfo", practiceID), nil, nil, out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.Practices) == 0 {
+		return nil, errors.New("Unexpected length returned")
+	}
+
+	return out.Practices[0], nil
+}
+
+type ListPracticesOptions struct {
+	Pagination *PaginationOptions
+}
+
+type ListPracticesResult struct {
+	Practices []*Practice
+
+	Pagination *PaginationResult
+}
+
+// ListPractices - List of the practices available to these credentials
+// GET /v1/1/practiceinfo
+// https://developer.athenahealth.com/docs/read/administrative/Practice_Info#section-1
+func (h *HTTPClient) ListPractices(ctx context.Context, opts *ListPracticesOptions) (*ListPracticesResult, error) {
+	out := &practiceInfoResponse{}
+
+	path := fmt.Sprintf("/%s/practiceinfo", allPracticesID)
+
+	q := url.Values{}
+
+	if opts != nil {
+		if opts.Pagination != nil {
+			if opts.Pagination.Limit > 0 {
+				q.Add("limit", strconv.Itoa(opts.Pagination.Limit))
+			}
+
+			if opts.Pagination.Offset > 0 {
+				q.Add("offset", strconv.Itoa(opts.Pagination.Offset))
+			}
+		}
+	}
+
+	if len(q) > 0 {
+		path = fmt.Sprintf("%s?%s", path, q.Encode())
+	}
+
+	_, err := h.unscopedRequest(ctx, "", "GET", path, nil, nil, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListPracticesResult{
+		Practices:  out.Practices,
+		Pagination: makePaginationResult(out.Next, out.Previous, out.TotalCount),
+	}, nil
+}
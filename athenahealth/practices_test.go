@@ -0,0 +1,83 @@
+package athenahealth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/asatish/go-athenahealth/athenahealth/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClient_GetPracticeInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	var paths []string
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		b, _ := ioutil.ReadFile("./resources/GetPracticeInfo.json")
+		w.Write(b)
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	var observations []*stats.Observation
+
+	athenaClient.WithBaseURL(ts.URL).WithStats(stats.ObserverFunc(func(o *stats.Observation) error {
+		observations = append(observations, o)
+		return nil
+	}))
+
+	practice, err := athenaClient.GetPracticeInfo(context.Background(), "195900")
+	assert.NoError(err)
+	assert.Equal("195900", practice.PracticeID)
+	assert.Equal("ATHENA TEST PRACTICE", practice.Name)
+	assert.True(bool(practice.HasClinicals))
+	assert.False(bool(practice.IsCoordinatorSender))
+
+	_, err = athenaClient.GetPracticeInfo(context.Background(), "")
+	assert.NoError(err)
+
+	assert.Equal([]string{"/195900/practiceinfo", "/" + testPracticeID + "/practiceinfo"}, paths)
+
+	// The requests are tagged with the practice they are about.
+	if assert.Len(observations, 2) {
+		assert.Equal("195900", observations[0].PracticeID)
+		assert.Equal(testPracticeID, observations[1].PracticeID)
+	}
+}
+
+func TestHTTPClient_ListPractices(t *testing.T) {
+	assert := assert.New(t)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		// Practices are listed outside of the client's practice.
+		assert.Equal("/1/practiceinfo", r.URL.Path)
+		assert.Equal("2", r.URL.Query().Get("limit"))
+
+		b, _ := ioutil.ReadFile("./resources/ListPractices.json")
+		w.Write(b)
+	}
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	athenaClient.WithBaseURL(ts.URL)
+
+	opts := &ListPracticesOptions{
+		Pagination: &PaginationOptions{
+			Limit: 2,
+		},
+	}
+
+	res, err := athenaClient.ListPractices(context.Background(), opts)
+	assert.NoError(err)
+	assert.Len(res.Practices, 2)
+	assert.Equal("195901", res.Practices[1].PracticeID)
+	assert.Equal(2, res.Pagination.NextOffset)
+	assert.Equal(3, res.Pagination.TotalCount)
+}
@@ -0,0 +1,25 @@
+package athenahealth
+
+import (
+	"context"
+
+	"github.com/asatish/go-athenahealth/athenahealth/ratelimiter"
+)
+
+// Priority is the rate limiter lane a request is made in. See ratelimiter.Redis's
+// WithInteractiveShare.
+type Priority = ratelimiter.Priority
+
+const (
+	// PriorityInteractive is for requests a user is waiting on. It is the default.
+	PriorityInteractive = ratelimiter.PriorityInteractive
+
+	// PriorityBatch is for background work, e.g. syncs and backfills, that can wait behind
+	// interactive requests.
+	PriorityBatch = ratelimiter.PriorityBatch
+)
+
+// WithPriority returns a copy of ctx whose requests are made with priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return ratelimiter.WithPriority(ctx, p)
+}
@@ -0,0 +1,38 @@
+package athenahealth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatish/go-athenahealth/athenahealth/ratelimiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPriority(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	var priorities []Priority
+
+	athenaClient.WithRateLimiter(rateLimiterFunc(func(ctx context.Context, preview bool) (time.Duration, error) {
+		priorities = append(priorities, ratelimiter.PriorityFromContext(ctx))
+		return 0, nil
+	}))
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	_, err = athenaClient.request(WithPriority(context.Background(), PriorityBatch), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	assert.Equal([]Priority{PriorityInteractive, PriorityBatch}, priorities)
+}
+
+type rateLimiterFunc func(ctx context.Context, preview bool) (time.Duration, error)
+
+func (f rateLimiterFunc) Allowed(ctx context.Context, preview bool) (time.Duration, error) {
+	return f(ctx, preview)
+}
@@ -136,3 +136,59 @@ func (h *HTTPClient) ListProviders(ctx context.Context, opts *ListProvidersOptio
 		Pagination: makePaginationResult(out.Next, out.Previous, out.TotalCount),
 	}, nil
 }
+
+// ProviderIterator pages through a list of providers. Call Next to advance and Value to get the
+// current provider.
+type ProviderIterator struct {
+	*iterator
+}
+
+// Value returns the current provider.
+func (i *ProviderIterator) Value() *Provider {
+	v, _ := i.current.(*Provider)
+	return v
+}
+
+// NewProviderIterator returns an iterator over providers, fetching pages from ListProviders as
+// needed.
+func NewProviderIterator(client Client, opts *ListProvidersOptions, iterOpts *IteratorOptions) *ProviderIterator {
+	var start *PaginationOptions
+	if opts != nil {
+		start = opts.Pagination
+	}
+
+	fetch := func(ctx context.Context, pagination *PaginationOptions) ([]interface{}, *PaginationResult, error) {
+		pageOpts := ListProvidersOptions{}
+		if opts != nil {
+			pageOpts = *opts
+		}
+		pageOpts.Pagination = pagination
+
+		res, err := client.ListProviders(ctx, &pageOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, len(res.Providers))
+		for i, item := range res.Providers {
+			items[i] = item
+		}
+
+		return items, res.Pagination, nil
+	}
+
+	return &ProviderIterator{newIterator(fetch, start, iterOpts)}
+}
+
+// ListAllProviders returns providers, fetching pages until there are no more, iterOpts.MaxItems is
+// reached or ctx is done. On error, the results fetched so far are returned with it.
+func (h *HTTPClient) ListAllProviders(ctx context.Context, opts *ListProvidersOptions, iterOpts *IteratorOptions) ([]*Provider, error) {
+	it := NewProviderIterator(h, opts, iterOpts)
+
+	var out []*Provider
+	for it.Next(ctx) {
+		out = append(out, it.Value())
+	}
+
+	return out, it.Err()
+}
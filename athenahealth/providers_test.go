@@ -75,3 +75,19 @@ func TestHTTPClient_ListProviders(t *testing.T) {
 	assert.Equal(res.Pagination.TotalCount, 1)
 	assert.NoError(err)
 }
+
+func TestHTTPClient_ListAllProviders(t *testing.T) {
+	assert := assert.New(t)
+
+	h := testPagedHandler("providers", 15, func(i int) interface{} {
+		return map[string]int{"providerid": i}
+	})
+
+	athenaClient, ts := testClient(h)
+	defer ts.Close()
+
+	providers, err := athenaClient.ListAllProviders(context.Background(), nil, &IteratorOptions{PageSize: 5})
+
+	assert.NoError(err)
+	assert.Len(providers, 15)
+}
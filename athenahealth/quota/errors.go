@@ -0,0 +1,33 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ExceededError is returned when a call would go over a hard limit.
+type ExceededError struct {
+	// Path is the endpoint template whose limit was reached, or empty if it was the limit on all
+	// calls.
+	Path string
+
+	// Period is the period of the limit that was reached, PeriodDay or PeriodMonth.
+	Period string
+
+	// Limit is the hard limit.
+	Limit int64
+}
+
+func (e *ExceededError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("%s: %d calls per %s", ErrQuotaExceeded, e.Limit, e.Period)
+	}
+
+	return fmt.Sprintf("%s: %d calls to %s per %s", ErrQuotaExceeded, e.Limit, e.Path, e.Period)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
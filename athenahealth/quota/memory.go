@@ -0,0 +1,100 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory counts calls in memory. Its counts only include calls made by the current process and
+// are lost when it exits, so use Redis to track calls from several processes.
+type Memory struct {
+	*tracker
+}
+
+type memoryCounter struct {
+	lock    sync.Mutex
+	entries map[string]*memoryEntry
+
+	now func() time.Time
+}
+
+type memoryEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// NewMemory returns a Memory tracker enforcing the limits in opts. Nil options track calls
+// without limits.
+func NewMemory(opts *Options) *Memory {
+	c := &memoryCounter{
+		entries: map[string]*memoryEntry{},
+	}
+
+	m := &Memory{
+		tracker: newTracker(c, opts),
+	}
+
+	c.now = func() time.Time { return m.now() }
+
+	return m
+}
+
+func (c *memoryCounter) incr(ctx context.Context, keys []string, ttls []time.Duration) ([]int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+
+	// Drop the counters of past periods.
+	for key, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	counts := make([]int64, len(keys))
+
+	for i, key := range keys {
+		e, ok := c.entries[key]
+		if !ok {
+			e = &memoryEntry{}
+			c.entries[key] = e
+		}
+
+		e.count++
+		e.expiresAt = now.Add(ttls[i])
+		counts[i] = e.count
+	}
+
+	return counts, nil
+}
+
+func (c *memoryCounter) decr(ctx context.Context, keys []string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, key := range keys {
+		if e, ok := c.entries[key]; ok {
+			e.count--
+		}
+	}
+
+	return nil
+}
+
+func (c *memoryCounter) get(ctx context.Context, keys []string) ([]int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	counts := make([]int64, len(keys))
+
+	for i, key := range keys {
+		if e, ok := c.entries[key]; ok && now.Before(e.expiresAt) {
+			counts[i] = e.count
+		}
+	}
+
+	return counts, nil
+}
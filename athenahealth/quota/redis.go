@@ -0,0 +1,106 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const RedisDefaultKeyPrefix = "athena_quota:"
+
+// Redis counts calls in Redis, so that calls from every process using the same Redis server count
+// towards the same limits.
+type Redis struct {
+	*tracker
+}
+
+type redisCounter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis returns a Redis tracker enforcing the limits in opts. Keys are prefixed with
+// RedisDefaultKeyPrefix if keyPrefix is empty. Nil options track calls without limits.
+func NewRedis(client *redis.Client, keyPrefix string, opts *Options) *Redis {
+	if client == nil {
+		panic("client is nil")
+	}
+
+	if len(keyPrefix) == 0 {
+		keyPrefix = RedisDefaultKeyPrefix
+	}
+
+	c := &redisCounter{
+		client: client,
+		prefix: keyPrefix,
+	}
+
+	return &Redis{
+		tracker: newTracker(c, opts),
+	}
+}
+
+func (c *redisCounter) incr(ctx context.Context, keys []string, ttls []time.Duration) ([]int64, error) {
+	pipe := c.client.TxPipeline()
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Incr(ctx, c.prefix+key)
+		pipe.Expire(ctx, c.prefix+key, ttls[i])
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(keys))
+	for i, cmd := range cmds {
+		counts[i] = cmd.Val()
+	}
+
+	return counts, nil
+}
+
+func (c *redisCounter) decr(ctx context.Context, keys []string) error {
+	pipe := c.client.TxPipeline()
+
+	for _, key := range keys {
+		pipe.Decr(ctx, c.prefix+key)
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (c *redisCounter) get(ctx context.Context, keys []string) ([]int64, error) {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+
+	vals, err := c.client.MGet(ctx, prefixed...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	counts := make([]int64, len(keys))
+
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		counts[i], err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
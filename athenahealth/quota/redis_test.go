@@ -0,0 +1,87 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedis_Track(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+
+	opts := &Options{
+		Endpoints: map[string]Budget{
+			"/patients/:id:": {
+				Hard: Limit{Daily: 2},
+			},
+		},
+	}
+
+	now := func() time.Time {
+		return time.Date(2021, 1, 2, 12, 0, 0, 0, time.UTC)
+	}
+
+	// Two trackers sharing Redis, as if they were in separate processes.
+	r1 := NewRedis(client, "", opts)
+	r1.now = now
+	r2 := NewRedis(client, "", opts)
+	r2.now = now
+
+	_, err = r1.Track(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+
+	usage, err := r2.Track(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.Equal(int64(2), usage.Day)
+
+	_, err = r1.Track(context.Background(), "/patients/:id:")
+	assert.True(errors.Is(err, ErrQuotaExceeded))
+
+	usage, err = r2.Usage(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.Equal(&Usage{
+		Path:       "/patients/:id:",
+		Day:        2,
+		Month:      2,
+		TotalDay:   2,
+		TotalMonth: 2,
+	}, usage)
+
+	v, err := s.Get("athena_quota:day:2021-01-02:/patients/:id:")
+	assert.NoError(err)
+	assert.Equal("2", v)
+	assert.True(s.TTL("athena_quota:day:2021-01-02:/patients/:id:") > 0)
+}
+
+func TestRedis_Usage_empty(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	r := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), "", nil)
+
+	usage, err := r.Usage(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.Equal(&Usage{Path: "/patients/:id:"}, usage)
+}
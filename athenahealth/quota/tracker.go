@@ -0,0 +1,195 @@
+package quota
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// How long counters are kept after they were last incremented. They outlive their period so that
+// usage can still be read around midnight.
+const (
+	dayTTL   = 48 * time.Hour
+	monthTTL = 63 * 24 * time.Hour
+)
+
+// totalPath is the path used to count all calls. Endpoint templates always start with "/".
+const totalPath = "total"
+
+// Limit is a number of calls allowed per day and per month. Zero means no limit.
+type Limit struct {
+	Daily   int64
+	Monthly int64
+}
+
+// Budget is the soft and hard limits on a number of calls. Going over a soft limit is logged and
+// reported in Usage. Calls that would go over a hard limit fail with an ExceededError.
+type Budget struct {
+	Soft Limit
+	Hard Limit
+}
+
+// Options configures the limits a tracker enforces.
+type Options struct {
+	// Total limits all calls.
+	Total Budget
+
+	// Endpoints limits calls to individual endpoints, keyed by endpoint template, e.g.
+	// "/patients/:id:".
+	Endpoints map[string]Budget
+}
+
+// Usage is the number of calls made in the current day and month. Days and months are in UTC.
+type Usage struct {
+	// Path is the endpoint template the usage is for.
+	Path string
+
+	// Day and Month are the calls made to Path.
+	Day   int64
+	Month int64
+
+	// TotalDay and TotalMonth are the calls made to all endpoints.
+	TotalDay   int64
+	TotalMonth int64
+
+	// SoftLimitExceeded is true if any soft limit that applies to Path has been exceeded.
+	SoftLimitExceeded bool
+}
+
+// counter stores the call counts for a tracker.
+type counter interface {
+	incr(ctx context.Context, keys []string, ttls []time.Duration) ([]int64, error)
+	decr(ctx context.Context, keys []string) error
+	get(ctx context.Context, keys []string) ([]int64, error)
+}
+
+// tracker counts calls per endpoint template and enforces limits on them. Memory and Redis embed
+// it with their own counter.
+type tracker struct {
+	counter counter
+	opts    Options
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+func newTracker(c counter, opts *Options) *tracker {
+	t := &tracker{
+		counter: c,
+		now:     time.Now,
+	}
+
+	if opts != nil {
+		t.opts = *opts
+	}
+
+	return t
+}
+
+// keys returns the current day and month keys for path and for all calls.
+func (t *tracker) keys(path string) []string {
+	now := t.now().UTC()
+	day := now.Format("2006-01-02")
+	month := now.Format("2006-01")
+
+	return []string{
+		PeriodDay + ":" + day + ":" + path,
+		PeriodMonth + ":" + month + ":" + path,
+		PeriodDay + ":" + day + ":" + totalPath,
+		PeriodMonth + ":" + month + ":" + totalPath,
+	}
+}
+
+// Track counts a call to the endpoint template path. If the call would go over a hard limit, it
+// isn't counted and an ExceededError is returned.
+func (t *tracker) Track(ctx context.Context, path string) (*Usage, error) {
+	keys := t.keys(path)
+
+	counts, err := t.counter.incr(ctx, keys, []time.Duration{dayTTL, monthTTL, dayTTL, monthTTL})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := t.usage(path, counts)
+
+	err = t.checkHard(usage)
+	if err != nil {
+		decrErr := t.counter.decr(ctx, keys)
+		if decrErr != nil {
+			return nil, decrErr
+		}
+
+		for i := range counts {
+			counts[i]--
+		}
+
+		return t.usage(path, counts), err
+	}
+
+	return usage, nil
+}
+
+// Usage returns the calls made to the endpoint template path without counting a new one.
+func (t *tracker) Usage(ctx context.Context, path string) (*Usage, error) {
+	counts, err := t.counter.get(ctx, t.keys(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return t.usage(path, counts), nil
+}
+
+func (t *tracker) usage(path string, counts []int64) *Usage {
+	usage := &Usage{
+		Path:       path,
+		Day:        counts[0],
+		Month:      counts[1],
+		TotalDay:   counts[2],
+		TotalMonth: counts[3],
+	}
+
+	endpoint := t.opts.Endpoints[path]
+
+	usage.SoftLimitExceeded = exceeds(usage.TotalDay, t.opts.Total.Soft.Daily) ||
+		exceeds(usage.TotalMonth, t.opts.Total.Soft.Monthly) ||
+		exceeds(usage.Day, endpoint.Soft.Daily) ||
+		exceeds(usage.Month, endpoint.Soft.Monthly)
+
+	return usage
+}
+
+func (t *tracker) checkHard(usage *Usage) error {
+	endpoint := t.opts.Endpoints[usage.Path]
+
+	checks := []struct {
+		path   string
+		period string
+		count  int64
+		limit  int64
+	}{
+		{"", PeriodDay, usage.TotalDay, t.opts.Total.Hard.Daily},
+		{"", PeriodMonth, usage.TotalMonth, t.opts.Total.Hard.Monthly},
+		{usage.Path, PeriodDay, usage.Day, endpoint.Hard.Daily},
+		{usage.Path, PeriodMonth, usage.Month, endpoint.Hard.Monthly},
+	}
+
+	for _, c := range checks {
+		if exceeds(c.count, c.limit) {
+			return &ExceededError{
+				Path:   c.path,
+				Period: c.period,
+				Limit:  c.limit,
+			}
+		}
+	}
+
+	return nil
+}
+
+func exceeds(count, limit int64) bool {
+	return limit > 0 && count > limit
+}
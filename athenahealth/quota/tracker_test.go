@@ -0,0 +1,144 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func testMemory(opts *Options) (*Memory, *testClock) {
+	clock := &testClock{
+		now: time.Date(2021, 1, 31, 23, 0, 0, 0, time.UTC),
+	}
+
+	m := NewMemory(opts)
+	m.now = clock.Now
+
+	return m, clock
+}
+
+func TestTracker_Track(t *testing.T) {
+	assert := assert.New(t)
+
+	m, clock := testMemory(nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := m.Track(context.Background(), "/patients/:id:")
+		assert.NoError(err)
+	}
+
+	usage, err := m.Track(context.Background(), "/departments")
+	assert.NoError(err)
+	assert.Equal(&Usage{
+		Path:       "/departments",
+		Day:        1,
+		Month:      1,
+		TotalDay:   4,
+		TotalMonth: 4,
+	}, usage)
+
+	// Both the day and the month start over at midnight UTC on the 1st.
+	clock.now = time.Date(2021, 2, 1, 0, 30, 0, 0, time.UTC)
+
+	usage, err = m.Usage(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.Equal(&Usage{
+		Path: "/patients/:id:",
+	}, usage)
+}
+
+func TestTracker_Track_newDay(t *testing.T) {
+	assert := assert.New(t)
+
+	m, clock := testMemory(nil)
+	clock.now = time.Date(2021, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	_, err := m.Track(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+
+	clock.now = time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC)
+
+	usage, err := m.Track(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.Equal(int64(1), usage.Day)
+	assert.Equal(int64(2), usage.Month)
+	assert.Equal(int64(1), usage.TotalDay)
+	assert.Equal(int64(2), usage.TotalMonth)
+}
+
+func TestTracker_Track_softLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := testMemory(&Options{
+		Endpoints: map[string]Budget{
+			"/patients/:id:": {
+				Soft: Limit{Daily: 2},
+			},
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		usage, err := m.Track(context.Background(), "/patients/:id:")
+		assert.NoError(err)
+		assert.False(usage.SoftLimitExceeded)
+	}
+
+	usage, err := m.Track(context.Background(), "/patients/:id:")
+	assert.NoError(err)
+	assert.True(usage.SoftLimitExceeded)
+
+	// The endpoint's limit doesn't apply to other endpoints.
+	usage, err = m.Track(context.Background(), "/departments")
+	assert.NoError(err)
+	assert.False(usage.SoftLimitExceeded)
+}
+
+func TestTracker_Track_hardLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := testMemory(&Options{
+		Total: Budget{
+			Hard: Limit{Monthly: 3},
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := m.Track(context.Background(), "/patients/:id:")
+		assert.NoError(err)
+	}
+
+	usage, err := m.Track(context.Background(), "/departments")
+	assert.True(errors.Is(err, ErrQuotaExceeded))
+	assert.Equal(&ExceededError{Period: PeriodMonth, Limit: 3}, err)
+	assert.Equal("quota exceeded: 3 calls per month", err.Error())
+	assert.Equal(int64(3), usage.TotalMonth)
+
+	// Rejected calls aren't counted.
+	usage, err = m.Usage(context.Background(), "/departments")
+	assert.NoError(err)
+	assert.Zero(usage.Day)
+	assert.Equal(int64(3), usage.TotalMonth)
+}
+
+func TestExceededError_Error(t *testing.T) {
+	assert := assert.New(t)
+
+	err := &ExceededError{
+		Path:   "/patients/:id:",
+		Period: PeriodDay,
+		Limit:  10,
+	}
+
+	assert.Equal("quota exceeded: 10 calls to /patients/:id: per day", err.Error())
+}
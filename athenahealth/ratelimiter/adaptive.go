@@ -0,0 +1,353 @@
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redis_rate/v9"
+)
+
+const redisKeyAdaptiveRatePreview = "athena_rate_limit:adaptive_rate:preview"
+const redisKeyAdaptiveRateProd = "athena_rate_limit:adaptive_rate:prod"
+
+// redisAdaptiveRateTTL is how long a learned rate is kept in Redis after it last changed.
+const redisAdaptiveRateTTL = 24 * time.Hour
+
+// AdaptiveOptions controls how an Adaptive rate limiter adjusts its rate.
+type AdaptiveOptions struct {
+	// MinRate is the lowest rate, in requests per second, the limiter decreases to. Defaults
+	// to 1.
+	MinRate float64
+
+	// MaxRate is the highest rate, in requests per second, the limiter increases to. Zero means no
+	// limit other than the plan's allotted calls per second when athenahealth reports it.
+	MaxRate float64
+
+	// Increase is the number of requests per second added to the rate after every
+	// IncreaseInterval without being throttled, as long as the limiter held back a request since
+	// the rate last changed. Defaults to 1.
+	Increase float64
+
+	// IncreaseInterval is how often the rate may increase or decrease. Defaults to one second.
+	IncreaseInterval time.Duration
+
+	// DecreaseFactor multiplies the rate when athenahealth throttles a request. Defaults to 0.5.
+	DecreaseFactor float64
+
+	// Burst is the number of requests allowed at once. Zero allows one second's worth of requests
+	// at the current rate.
+	Burst int
+
+	// SyncInterval is how often the rate learned by other processes is loaded from Redis.
+	// Defaults to one second. It is only used by limiters created with NewAdaptiveRedis.
+	SyncInterval time.Duration
+}
+
+func (o *AdaptiveOptions) setDefaults() {
+	if o.MinRate <= 0 {
+		o.MinRate = 1
+	}
+
+	if o.Increase <= 0 {
+		o.Increase = 1
+	}
+
+	if o.IncreaseInterval <= 0 {
+		o.IncreaseInterval = time.Second
+	}
+
+	if o.DecreaseFactor <= 0 || o.DecreaseFactor >= 1 {
+		o.DecreaseFactor = 0.5
+	}
+
+	if o.SyncInterval <= 0 {
+		o.SyncInterval = time.Second
+	}
+}
+
+// Adaptive is a rate limiter that adjusts its rate using AIMD (additive increase, multiplicative
+// decrease) based on athenahealth's responses. HTTPClient passes it feedback about every response:
+// the rate increases steadily while requests succeed and is cut when athenahealth throttles a
+// request, and requests are held back for as long as a Retry-After header asks.
+type Adaptive struct {
+	lock sync.Mutex
+
+	opts AdaptiveOptions
+
+	preview *adaptiveState
+	prod    *adaptiveState
+
+	// client and limiter are set when the rate is shared through Redis.
+	client  *redis.Client
+	limiter *redis_rate.Limiter
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+type adaptiveState struct {
+	rate    float64
+	maxRate float64
+
+	// bucket enforces the rate when it isn't enforced in Redis.
+	bucket *bucket
+
+	lastChange   time.Time
+	blockedUntil time.Time
+	lastSync     time.Time
+
+	// limited is set when the rate held back a request since it last changed. The rate only
+	// increases while it is the bottleneck, so that light traffic can't push it up without bound.
+	limited bool
+
+	limitKey string
+	rateKey  string
+}
+
+// NewAdaptive returns an Adaptive rate limiter that starts at ratePreview and rateProd requests
+// per second and enforces the rate in memory. Zero rates use the defaults, and nil options use the
+// default options.
+func NewAdaptive(ratePreview, rateProd int, opts *AdaptiveOptions) *Adaptive {
+	a := &Adaptive{
+		now: time.Now,
+	}
+
+	if opts != nil {
+		a.opts = *opts
+	}
+	a.opts.setDefaults()
+
+	if ratePreview <= 0 {
+		ratePreview = defaultRatePerSecPreview
+	}
+
+	if rateProd <= 0 {
+		rateProd = defaultRatePerSecProd
+	}
+
+	a.preview = a.newState(float64(ratePreview), redisKeyPreview, redisKeyAdaptiveRatePreview)
+	a.prod = a.newState(float64(rateProd), redisKeyProd, redisKeyAdaptiveRateProd)
+
+	return a
+}
+
+// NewAdaptiveRedis returns an Adaptive rate limiter that enforces its rate in Redis and shares the
+// rate it learns with every other process using the same Redis server.
+func NewAdaptiveRedis(client *redis.Client, ratePreview, rateProd int, opts *AdaptiveOptions) *Adaptive {
+	if client == nil {
+		panic("client is nil")
+	}
+
+	a := NewAdaptive(ratePreview, rateProd, opts)
+	a.client = client
+	a.limiter = redis_rate.NewLimiter(client)
+
+	return a
+}
+
+func (a *Adaptive) newState(rate float64, limitKey, rateKey string) *adaptiveState {
+	s := &adaptiveState{
+		rate:     rate,
+		maxRate:  a.opts.MaxRate,
+		limitKey: limitKey,
+		rateKey:  rateKey,
+	}
+
+	s.rate = a.clamp(s, rate)
+	s.bucket = &bucket{
+		rate:   s.rate,
+		burst:  a.burst(s.rate),
+		tokens: a.burst(s.rate),
+	}
+
+	return s
+}
+
+func (a *Adaptive) state(preview bool) *adaptiveState {
+	if preview {
+		return a.preview
+	}
+
+	return a.prod
+}
+
+// Rate returns the current rate in requests per second.
+func (a *Adaptive) Rate(preview bool) float64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.state(preview).rate
+}
+
+func (a *Adaptive) Allowed(ctx context.Context, preview bool) (time.Duration, error) {
+	s := a.state(preview)
+
+	if a.client != nil {
+		err := a.sync(ctx, s)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	a.lock.Lock()
+
+	now := a.now()
+	if now.Before(s.blockedUntil) {
+		a.lock.Unlock()
+		return s.blockedUntil.Sub(now), ErrRateExceeded
+	}
+
+	if a.client == nil {
+		defer a.lock.Unlock()
+
+		retryAfter, err := s.bucket.take(now)
+		if err != nil {
+			s.limited = true
+		}
+
+		return retryAfter, err
+	}
+
+	rate := s.rate
+	a.lock.Unlock()
+
+	res, err := a.limiter.Allow(ctx, s.limitKey, redis_rate.Limit{
+		Rate:   int(math.Max(1, math.Round(rate*60))),
+		Burst:  int(a.burst(rate)),
+		Period: time.Minute,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if res.RetryAfter > 0 {
+		a.lock.Lock()
+		s.limited = true
+		a.lock.Unlock()
+
+		return res.RetryAfter, ErrRateExceeded
+	}
+
+	return 0, nil
+}
+
+// Feedback adjusts the rate based on a response from athenahealth. The rate is cut by
+// DecreaseFactor when the response was throttled, and increased by Increase when it wasn't, the
+// rate hasn't changed for IncreaseInterval and the limiter held back a request since it last
+// changed. A 429's Retry-After holds back every request until it has passed.
+func (a *Adaptive) Feedback(ctx context.Context, preview bool, f *Feedback) error {
+	s := a.state(preview)
+
+	a.lock.Lock()
+
+	now := a.now()
+	rate := s.rate
+
+	if f.QPSAllotted > 0 {
+		s.maxRate = float64(f.QPSAllotted)
+		if a.opts.MaxRate > 0 {
+			s.maxRate = math.Min(a.opts.MaxRate, s.maxRate)
+		}
+	}
+
+	if f.Throttled() {
+		if f.RetryAfter > 0 && now.Add(f.RetryAfter).After(s.blockedUntil) {
+			s.blockedUntil = now.Add(f.RetryAfter)
+		}
+
+		// A burst of throttled responses is usually caused by the same excess, so only cut the
+		// rate once per interval.
+		if now.Sub(s.lastChange) >= a.opts.IncreaseInterval {
+			rate *= a.opts.DecreaseFactor
+			s.lastChange = now
+		}
+	} else if f.StatusCode < 500 && s.limited && now.Sub(s.lastChange) >= a.opts.IncreaseInterval {
+		rate += a.opts.Increase
+		s.lastChange = now
+	}
+
+	rate = a.clamp(s, rate)
+	changed := rate != s.rate
+	if changed {
+		a.setRate(s, rate, now)
+	}
+
+	a.lock.Unlock()
+
+	if changed && a.client != nil {
+		return a.client.Set(ctx, s.rateKey, strconv.FormatFloat(rate, 'f', -1, 64), redisAdaptiveRateTTL).Err()
+	}
+
+	return nil
+}
+
+// sync loads the rate shared through Redis if it hasn't been loaded for SyncInterval.
+func (a *Adaptive) sync(ctx context.Context, s *adaptiveState) error {
+	a.lock.Lock()
+	due := a.now().Sub(s.lastSync) >= a.opts.SyncInterval
+	a.lock.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	v, err := a.client.Get(ctx, s.rateKey).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := a.now()
+	s.lastSync = now
+
+	if err == redis.Nil {
+		return nil
+	}
+
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil
+	}
+
+	// Only a change counts as a new rate. Setting the same one again would clear limited and
+	// hold back the next increase.
+	rate = a.clamp(s, rate)
+	if rate != s.rate {
+		a.setRate(s, rate, now)
+	}
+
+	return nil
+}
+
+func (a *Adaptive) setRate(s *adaptiveState, rate float64, now time.Time) {
+	s.bucket.refill(now)
+
+	s.limited = false
+
+	s.rate = rate
+	s.bucket.rate = rate
+	s.bucket.burst = a.burst(rate)
+	s.bucket.tokens = math.Min(s.bucket.tokens, s.bucket.burst)
+}
+
+func (a *Adaptive) clamp(s *adaptiveState, rate float64) float64 {
+	if s.maxRate > 0 && rate > s.maxRate {
+		rate = s.maxRate
+	}
+
+	return math.Max(a.opts.MinRate, rate)
+}
+
+func (a *Adaptive) burst(rate float64) float64 {
+	if a.opts.Burst > 0 {
+		return float64(a.opts.Burst)
+	}
+
+	return math.Max(1, math.Floor(rate))
+}
@@ -0,0 +1,247 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAdaptive(ratePreview, rateProd int, opts *AdaptiveOptions) (*Adaptive, *testClock) {
+	clock := &testClock{
+		now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	a := NewAdaptive(ratePreview, rateProd, opts)
+	a.now = clock.Now
+
+	return a, clock
+}
+
+func TestFeedback_Throttled(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True((&Feedback{StatusCode: 429, QPSCurrent: -1, QuotaCurrent: -1}).Throttled())
+	assert.True((&Feedback{StatusCode: 200, QPSAllotted: 10, QPSCurrent: 10, QuotaCurrent: -1}).Throttled())
+	assert.True((&Feedback{StatusCode: 200, QPSCurrent: -1, QuotaAllotted: 100, QuotaCurrent: 100}).Throttled())
+	assert.False((&Feedback{StatusCode: 200, QPSAllotted: 10, QPSCurrent: 3, QuotaCurrent: -1}).Throttled())
+	assert.False((&Feedback{StatusCode: 503, QPSCurrent: -1, QuotaCurrent: -1}).Throttled())
+}
+
+// saturate makes requests until the rate holds one back.
+func saturate(a *Adaptive, preview bool) {
+	for {
+		_, err := a.Allowed(context.Background(), preview)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestAdaptive_Feedback_aimd(t *testing.T) {
+	assert := assert.New(t)
+
+	a, clock := testAdaptive(5, 20, &AdaptiveOptions{
+		MaxRate: 8,
+	})
+
+	ok := &Feedback{StatusCode: 200, QPSCurrent: -1, QuotaCurrent: -1}
+	throttled := &Feedback{StatusCode: 429, QPSCurrent: -1, QuotaCurrent: -1}
+
+	saturate(a, true)
+	assert.NoError(a.Feedback(context.Background(), true, ok))
+	assert.Equal(6.0, a.Rate(true))
+
+	// The rate changes at most once per interval.
+	saturate(a, true)
+	assert.NoError(a.Feedback(context.Background(), true, ok))
+	assert.Equal(6.0, a.Rate(true))
+
+	for i := 0; i < 5; i++ {
+		clock.Advance(time.Second)
+		saturate(a, true)
+		assert.NoError(a.Feedback(context.Background(), true, ok))
+	}
+	assert.Equal(8.0, a.Rate(true))
+
+	clock.Advance(time.Second)
+	assert.NoError(a.Feedback(context.Background(), true, throttled))
+	assert.NoError(a.Feedback(context.Background(), true, throttled))
+	assert.Equal(4.0, a.Rate(true))
+
+	clock.Advance(time.Second)
+	assert.NoError(a.Feedback(context.Background(), true, throttled))
+	clock.Advance(time.Second)
+	assert.NoError(a.Feedback(context.Background(), true, throttled))
+	clock.Advance(time.Second)
+	assert.NoError(a.Feedback(context.Background(), true, throttled))
+	assert.Equal(1.0, a.Rate(true))
+
+	// Production is adjusted separately. MaxRate caps the configured rate too.
+	assert.Equal(8.0, a.Rate(false))
+}
+
+func TestAdaptive_Feedback_notLimited(t *testing.T) {
+	assert := assert.New(t)
+
+	a, clock := testAdaptive(5, 20, nil)
+
+	ok := &Feedback{StatusCode: 200, QPSCurrent: -1, QuotaCurrent: -1}
+
+	// Light traffic never waits for the limiter, so the rate isn't the bottleneck and there's no
+	// reason to raise it, even without a MaxRate.
+	for i := 0; i < 3600; i++ {
+		clock.Advance(time.Second)
+
+		_, err := a.Allowed(context.Background(), true)
+		assert.NoError(err)
+		assert.NoError(a.Feedback(context.Background(), true, ok))
+	}
+	assert.Equal(5.0, a.Rate(true))
+
+	// Once a request is held back, the rate increases again.
+	saturate(a, true)
+	assert.NoError(a.Feedback(context.Background(), true, ok))
+	assert.Equal(6.0, a.Rate(true))
+}
+
+func TestAdaptive_Feedback_qpsAllotted(t *testing.T) {
+	assert := assert.New(t)
+
+	a, _ := testAdaptive(5, 100, nil)
+
+	err := a.Feedback(context.Background(), false, &Feedback{
+		StatusCode:   200,
+		QPSAllotted:  25,
+		QPSCurrent:   3,
+		QuotaCurrent: -1,
+	})
+	assert.NoError(err)
+	assert.Equal(25.0, a.Rate(false))
+}
+
+func TestAdaptive_Allowed(t *testing.T) {
+	assert := assert.New(t)
+
+	a, clock := testAdaptive(2, 100, nil)
+
+	for i := 0; i < 2; i++ {
+		retryAfter, err := a.Allowed(context.Background(), true)
+		assert.Zero(retryAfter)
+		assert.NoError(err)
+	}
+
+	retryAfter, err := a.Allowed(context.Background(), true)
+	assert.Equal(500*time.Millisecond, retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+
+	clock.Advance(time.Second)
+
+	err = a.Feedback(context.Background(), true, &Feedback{
+		StatusCode:   429,
+		RetryAfter:   3 * time.Second,
+		QPSCurrent:   -1,
+		QuotaCurrent: -1,
+	})
+	assert.NoError(err)
+
+	retryAfter, err = a.Allowed(context.Background(), true)
+	assert.Equal(3*time.Second, retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+
+	clock.Advance(3 * time.Second)
+
+	retryAfter, err = a.Allowed(context.Background(), true)
+	assert.Zero(retryAfter)
+	assert.NoError(err)
+}
+
+func TestAdaptiveRedis_sharedRate(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+
+	a1 := NewAdaptiveRedis(client, 10, 100, nil)
+	a2 := NewAdaptiveRedis(client, 10, 100, &AdaptiveOptions{
+		SyncInterval: time.Nanosecond,
+	})
+
+	err = a1.Feedback(context.Background(), false, &Feedback{
+		StatusCode:   429,
+		QPSCurrent:   -1,
+		QuotaCurrent: -1,
+	})
+	assert.NoError(err)
+	assert.Equal(50.0, a1.Rate(false))
+
+	v, err := s.Get(redisKeyAdaptiveRateProd)
+	assert.NoError(err)
+	assert.Equal("50", v)
+
+	retryAfter, err := a2.Allowed(context.Background(), false)
+	assert.Zero(retryAfter)
+	assert.NoError(err)
+	assert.Equal(50.0, a2.Rate(false))
+	assert.Equal(10.0, a2.Rate(true))
+}
+
+func TestAdaptiveRedis_Allowed(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	a := NewAdaptiveRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), 1, 1, nil)
+
+	retryAfter, err := a.Allowed(context.Background(), true)
+	assert.Zero(retryAfter)
+	assert.NoError(err)
+
+	retryAfter, err = a.Allowed(context.Background(), true)
+	assert.NotZero(retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+}
+
+func TestAdaptiveRedis_sync_keepsLimited(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	a := NewAdaptiveRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), 10, 100, nil)
+
+	a.prod.limited = true
+
+	// Loading the rate the limiter already has doesn't forget that it was holding requests back.
+	s.Set(redisKeyAdaptiveRateProd, "100")
+	assert.NoError(a.sync(context.Background(), a.prod))
+	assert.True(a.prod.limited)
+
+	// A new rate does.
+	s.Set(redisKeyAdaptiveRateProd, "50")
+	a.prod.lastSync = time.Time{}
+	assert.NoError(a.sync(context.Background(), a.prod))
+	assert.Equal(50.0, a.Rate(false))
+	assert.False(a.prod.limited)
+}
@@ -0,0 +1,41 @@
+package ratelimiter
+
+import "time"
+
+// Feedback describes a response from athenahealth that a rate limiter can use to adjust its rate.
+type Feedback struct {
+	// StatusCode is the response's status code.
+	StatusCode int
+
+	// RetryAfter is the wait requested by the response's Retry-After header, or 0 if it has none.
+	RetryAfter time.Duration
+
+	// QPSAllotted is the number of calls per second allowed by the practice's plan, or 0 if the
+	// response doesn't say.
+	QPSAllotted int
+
+	// QPSCurrent is the number of calls made in the current second, or -1 if the response doesn't
+	// say.
+	QPSCurrent int
+
+	// QuotaAllotted is the number of calls allowed in the current quota period, or 0 if the
+	// response doesn't say.
+	QuotaAllotted int
+
+	// QuotaCurrent is the number of calls made in the current quota period, or -1 if the response
+	// doesn't say.
+	QuotaCurrent int
+}
+
+// Throttled reports whether athenahealth rejected the request because of its rate or quota.
+func (f *Feedback) Throttled() bool {
+	if f.StatusCode == 429 {
+		return true
+	}
+
+	if f.QPSAllotted > 0 && f.QPSCurrent >= f.QPSAllotted {
+		return true
+	}
+
+	return f.QuotaAllotted > 0 && f.QuotaCurrent >= f.QuotaAllotted
+}
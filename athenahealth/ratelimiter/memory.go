@@ -0,0 +1,99 @@
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Memory is a token bucket rate limiter that keeps its state in memory. It only limits requests
+// made by the current process, so use Redis when requests are made from several processes.
+type Memory struct {
+	lock sync.Mutex
+
+	preview *bucket
+	prod    *bucket
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// bucket holds up to burst tokens and refills at rate tokens per second.
+type bucket struct {
+	rate  float64
+	burst float64
+
+	tokens float64
+	last   time.Time
+}
+
+// NewMemory returns a Memory rate limiter allowing ratePreview and rateProd requests per second,
+// with bursts of up to burst requests. Zero values use the default rates and a burst of one
+// second's worth of requests.
+func NewMemory(ratePreview, rateProd, burst int) *Memory {
+	if ratePreview <= 0 {
+		ratePreview = defaultRatePerSecPreview
+	}
+
+	if rateProd <= 0 {
+		rateProd = defaultRatePerSecProd
+	}
+
+	return &Memory{
+		preview: newBucket(ratePreview, burst),
+		prod:    newBucket(rateProd, burst),
+
+		now: time.Now,
+	}
+}
+
+func newBucket(rate, burst int) *bucket {
+	if burst <= 0 {
+		burst = rate
+	}
+
+	return &bucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+func (m *Memory) Allowed(ctx context.Context, preview bool) (time.Duration, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	b := m.prod
+	if preview {
+		b = m.preview
+	}
+
+	return b.take(m.now())
+}
+
+// refill adds the tokens accrued since the last call, up to burst.
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+
+	if now.After(b.last) {
+		b.last = now
+	}
+}
+
+// take refills the bucket for the time elapsed since the last call and takes a token from it. If
+// the bucket is empty it returns how long until the next token is available.
+func (b *bucket) take(now time.Time) (time.Duration, error) {
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, nil
+	}
+
+	retryAfter := time.Duration(math.Ceil((1 - b.tokens) / b.rate * float64(time.Second)))
+
+	return retryAfter, ErrRateExceeded
+}
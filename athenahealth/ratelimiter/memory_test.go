@@ -0,0 +1,131 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func testMemory(ratePreview, rateProd, burst int) (*Memory, *testClock) {
+	clock := &testClock{
+		now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	m := NewMemory(ratePreview, rateProd, burst)
+	m.now = clock.Now
+
+	return m, clock
+}
+
+func TestNewMemory(t *testing.T) {
+	assert := assert.New(t)
+
+	m := NewMemory(0, 0, 0)
+
+	assert.Equal(float64(defaultRatePerSecPreview), m.preview.rate)
+	assert.Equal(float64(defaultRatePerSecPreview), m.preview.burst)
+	assert.Equal(float64(defaultRatePerSecProd), m.prod.rate)
+	assert.Equal(float64(defaultRatePerSecProd), m.prod.burst)
+}
+
+func TestMemory_Allowed(t *testing.T) {
+	assert := assert.New(t)
+
+	m, clock := testMemory(2, 10, 2)
+
+	for i := 0; i < 2; i++ {
+		retryAfter, err := m.Allowed(context.Background(), true)
+		assert.Zero(retryAfter)
+		assert.NoError(err)
+	}
+
+	retryAfter, err := m.Allowed(context.Background(), true)
+	assert.Equal(500*time.Millisecond, retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+
+	// Preview and production have separate buckets.
+	retryAfter, err = m.Allowed(context.Background(), false)
+	assert.Zero(retryAfter)
+	assert.NoError(err)
+
+	clock.Advance(200 * time.Millisecond)
+
+	retryAfter, err = m.Allowed(context.Background(), true)
+	assert.Equal(300*time.Millisecond, retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+
+	clock.Advance(retryAfter)
+
+	retryAfter, err = m.Allowed(context.Background(), true)
+	assert.Zero(retryAfter)
+	assert.NoError(err)
+
+	retryAfter, err = m.Allowed(context.Background(), true)
+	assert.Equal(500*time.Millisecond, retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+}
+
+func TestMemory_Allowed_refillCappedAtBurst(t *testing.T) {
+	assert := assert.New(t)
+
+	m, clock := testMemory(5, 5, 3)
+
+	clock.Advance(time.Hour)
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		_, err := m.Allowed(context.Background(), true)
+		if err == nil {
+			allowed++
+		}
+	}
+
+	assert.Equal(3, allowed)
+}
+
+func TestMemory_Allowed_concurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := testMemory(5, 100, 0)
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	allowed := 0
+
+	for i := 0; i < 150; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := m.Allowed(context.Background(), false)
+			if err == nil {
+				lock.Lock()
+				allowed++
+				lock.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// The clock doesn't move, so only the initial burst is allowed.
+	retryAfter, err := m.Allowed(context.Background(), false)
+	assert.Equal(100, allowed)
+	assert.Equal(ErrRateExceeded, err)
+	assert.Equal(10*time.Millisecond, retryAfter)
+}
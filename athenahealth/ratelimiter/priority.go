@@ -0,0 +1,41 @@
+package ratelimiter
+
+import "context"
+
+// Priority is the lane a request is rate limited in.
+type Priority int
+
+const (
+	// PriorityInteractive is for requests a user is waiting on. It is the default.
+	PriorityInteractive Priority = iota
+
+	// PriorityBatch is for background work, e.g. syncs and backfills, that can wait behind
+	// interactive requests.
+	PriorityBatch
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityBatch:
+		return "batch"
+	default:
+		return "interactive"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx whose requests are rate limited in the p lane.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority stored in ctx by WithPriority, or PriorityInteractive
+// if there isn't one.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+
+	return PriorityInteractive
+}
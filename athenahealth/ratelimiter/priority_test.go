@@ -0,0 +1,17 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityFromContext(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(PriorityInteractive, PriorityFromContext(context.Background()))
+	assert.Equal(PriorityBatch, PriorityFromContext(WithPriority(context.Background(), PriorityBatch)))
+	assert.Equal("batch", PriorityBatch.String())
+	assert.Equal("interactive", PriorityInteractive.String())
+}
@@ -2,6 +2,8 @@ package ratelimiter
 
 import (
 	"context"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,6 +13,53 @@ import (
 const redisKeyPreview = "athena_rate_limit:preview"
 const redisKeyProd = "athena_rate_limit:prod"
 
+// redisKeyBatchSuffix is appended to the key of the batch lane's counter.
+const redisKeyBatchSuffix = ":batch"
+
+// redisRateKeyPrefix is the prefix redis_rate adds to its keys. redisAllowBatchScript uses it too,
+// so that batch requests count against the same total as requests limited by redis_rate.
+const redisRateKeyPrefix = "rate:"
+
+// redisAllowBatchScript takes a slot in each of KEYS, limited to ARGV rates per second, only if
+// every key has one free. It uses the same GCRA state as redis_rate, with a burst of one second's
+// worth of requests. It returns the seconds until a slot is free in every key, or -1 if the slots
+// were taken.
+var redisAllowBatchScript = redis.NewScript(`
+redis.replicate_commands()
+
+local jan_1_2017 = 1483228800
+local now = redis.call("TIME")
+now = (now[1] - jan_1_2017) + (now[2] / 1000000)
+
+local retry_after = 0
+local new_tats = {}
+
+for i, key in ipairs(KEYS) do
+	local emission_interval = 1 / tonumber(ARGV[i])
+
+	local tat = tonumber(redis.call("GET", key)) or now
+	tat = math.max(tat, now)
+
+	local new_tat = tat + emission_interval
+	local diff = now - (new_tat - 1)
+	if diff < 0 then
+		retry_after = math.max(retry_after, -diff)
+	end
+
+	new_tats[i] = new_tat
+end
+
+if retry_after > 0 then
+	return tostring(retry_after)
+end
+
+for i, key in ipairs(KEYS) do
+	redis.call("SET", key, new_tats[i], "EX", math.ceil(new_tats[i] - now))
+end
+
+return "-1"
+`)
+
 const defaultRatePerSecPreview = 5
 const defaultRatePerSecProd = 100
 
@@ -20,6 +69,9 @@ type Redis struct {
 
 	ratePreivew int
 	rateProd    int
+
+	// interactiveShare is the fraction of capacity reserved for interactive requests.
+	interactiveShare float64
 }
 
 func NewRedis(client *redis.Client, ratePreview, rateProd int) *Redis {
@@ -46,18 +98,63 @@ func NewRedis(client *redis.Client, ratePreview, rateProd int) *Redis {
 	return r
 }
 
+// WithInteractiveShare reserves share (0-1) of the rate for requests with PriorityInteractive.
+// Requests with PriorityBatch are counted in their own lane, which is limited to the rest of the
+// rate, as well as against the total rate. A batch request only takes a slot in either when both
+// allow it. Interactive requests can always use the full rate.
+func (r *Redis) WithInteractiveShare(share float64) *Redis {
+	r.interactiveShare = math.Max(0, math.Min(share, 1))
+
+	return r
+}
+
 func (r *Redis) Allowed(ctx context.Context, preview bool) (time.Duration, error) {
 	var key string
-	var limit redis_rate.Limit
+	var rate int
 
 	if preview {
 		key = redisKeyPreview
-		limit = redis_rate.PerSecond(r.ratePreivew)
+		rate = r.ratePreivew
 	} else {
 		key = redisKeyProd
-		limit = redis_rate.PerSecond(r.rateProd)
+		rate = r.rateProd
 	}
 
+	if r.interactiveShare > 0 && PriorityFromContext(ctx) == PriorityBatch {
+		batchRate := int(math.Max(1, math.Floor(float64(rate)*(1-r.interactiveShare))))
+
+		return r.allowBatch(ctx, key, rate, batchRate)
+	}
+
+	return r.allow(ctx, key, redis_rate.PerSecond(rate))
+}
+
+// allowBatch takes a slot in both the total and the batch lane of key, or in neither if either is
+// full.
+func (r *Redis) allowBatch(ctx context.Context, key string, rate, batchRate int) (time.Duration, error) {
+	keys := []string{
+		redisRateKeyPrefix + key,
+		redisRateKeyPrefix + key + redisKeyBatchSuffix,
+	}
+
+	v, err := redisAllowBatchScript.Run(ctx, r.client, keys, rate, batchRate).Text()
+	if err != nil {
+		return 0, err
+	}
+
+	retryAfter, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if retryAfter > 0 {
+		return time.Duration(retryAfter * float64(time.Second)), ErrRateExceeded
+	}
+
+	return 0, nil
+}
+
+func (r *Redis) allow(ctx context.Context, key string, limit redis_rate.Limit) (time.Duration, error) {
 	res, err := r.limiter.Allow(ctx, key, limit)
 	if err != nil {
 		return 0, err
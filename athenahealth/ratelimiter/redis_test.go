@@ -11,6 +11,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// redisRateEpoch is the epoch redis_rate measures time from. Tests that freeze Redis' clock use it
+// so that the rate limiter's floating point arithmetic starts from zero and doesn't lose
+// precision to a large timestamp.
+var redisRateEpoch = time.Unix(1483228800, 0)
+
 func TestRedis_Allowed(t *testing.T) {
 	assert := assert.New(t)
 
@@ -54,3 +59,117 @@ func TestRedis_Allowed(t *testing.T) {
 	assert.Zero(retryAfter)
 	assert.NoError(err)
 }
+
+func TestRedis_Allowed_priority(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	// Freeze Redis' clock so that no slots free up while the test runs.
+	s.SetTime(redisRateEpoch)
+
+	rateLimiter := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), 10, 10).WithInteractiveShare(0.4)
+
+	batchCtx := WithPriority(context.Background(), PriorityBatch)
+
+	batchAllowed := 0
+	for i := 0; i < 10; i++ {
+		_, err := rateLimiter.Allowed(batchCtx, true)
+		if err == nil {
+			batchAllowed++
+		}
+	}
+
+	// Batch requests are limited to the share that isn't reserved for interactive requests.
+	assert.Equal(6, batchAllowed)
+
+	interactiveAllowed := 0
+	for i := 0; i < 10; i++ {
+		_, err := rateLimiter.Allowed(context.Background(), true)
+		if err == nil {
+			interactiveAllowed++
+		}
+	}
+
+	assert.Equal(4, interactiveAllowed)
+}
+
+func TestRedis_Allowed_interactiveUsesFullRate(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	// Freeze Redis' clock so that no slots free up while the test runs.
+	s.SetTime(redisRateEpoch)
+
+	rateLimiter := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), 10, 10).WithInteractiveShare(0.4)
+
+	allowed := 0
+	for i := 0; i < 12; i++ {
+		_, err := rateLimiter.Allowed(context.Background(), true)
+		if err == nil {
+			allowed++
+		}
+	}
+
+	assert.Equal(10, allowed)
+
+	retryAfter, err := rateLimiter.Allowed(WithPriority(context.Background(), PriorityBatch), true)
+	assert.NotZero(retryAfter)
+	assert.Equal(ErrRateExceeded, err)
+}
+
+func TestRedis_Allowed_batchRejectedByTotal(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	s.SetTime(redisRateEpoch)
+
+	rateLimiter := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), 10, 10).WithInteractiveShare(0.4)
+
+	batchCtx := WithPriority(context.Background(), PriorityBatch)
+
+	for i := 0; i < 10; i++ {
+		_, err := rateLimiter.Allowed(context.Background(), true)
+		assert.NoError(err)
+	}
+
+	// The total lane is full, so these are rejected without using up the batch lane.
+	for i := 0; i < 10; i++ {
+		retryAfter, err := rateLimiter.Allowed(batchCtx, true)
+		assert.Equal(ErrRateExceeded, err)
+		assert.Equal(100*time.Millisecond, retryAfter.Round(time.Millisecond))
+	}
+
+	// Once the total lane has room again, the batch lane still has its full share.
+	s.SetTime(redisRateEpoch.Add(2 * time.Second))
+
+	batchAllowed := 0
+	for i := 0; i < 10; i++ {
+		_, err := rateLimiter.Allowed(batchCtx, true)
+		if err == nil {
+			batchAllowed++
+		}
+	}
+
+	assert.Equal(6, batchAllowed)
+}
@@ -0,0 +1,239 @@
+package athenahealth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultRefreshFraction is the fraction of a token's lifetime after which the background
+// refresher replaces it if WithBackgroundTokenRefresh is given an invalid fraction.
+const defaultRefreshFraction = 0.75
+
+// minRefreshInterval is the minimum time between background refreshes, so that tokens with very
+// short lifetimes don't cause a busy loop.
+const minRefreshInterval = time.Second
+
+// tokenExpiryGetter is implemented by TokenCachers that can return when their token expires. All
+// of the cachers in the tokencacher package implement it.
+type tokenExpiryGetter interface {
+	GetWithExpiry(context.Context) (string, time.Time, error)
+}
+
+// tokenRefresher replaces the token in the background before it expires.
+type tokenRefresher struct {
+	fraction    float64
+	minInterval time.Duration
+
+	// backoff is the backoff between failed refreshes.
+	backoff *RetryPolicy
+
+	startOnce sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	// updated is signaled whenever a new token is fetched, so that the next refresh is rescheduled.
+	updated chan struct{}
+
+	lock      sync.Mutex
+	closed    bool
+	token     string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// WithBackgroundTokenRefresh replaces the token in the background once fraction (0-1) of its
+// lifetime has passed, so that requests don't wait for a new token when it expires. Failed
+// refreshes are retried with exponential backoff. The refresher starts with the first request and
+// runs until Close is called.
+func (h *HTTPClient) WithBackgroundTokenRefresh(fraction float64) *HTTPClient {
+	if fraction <= 0 || fraction >= 1 {
+		fraction = defaultRefreshFraction
+	}
+
+	h.refresher = &tokenRefresher{
+		fraction:    fraction,
+		minInterval: minRefreshInterval,
+		backoff: &RetryPolicy{
+			BaseBackoff: time.Second,
+			MaxBackoff:  time.Minute,
+			Jitter:      0.2,
+		},
+		done:    make(chan struct{}),
+		updated: make(chan struct{}, 1),
+	}
+
+	return h
+}
+
+// Close stops the background token refresher, if there is one, and waits for it to exit.
+func (h *HTTPClient) Close() error {
+	r := h.refresher
+	if r == nil {
+		return nil
+	}
+
+	r.lock.Lock()
+	r.closed = true
+	cancel := r.cancel
+	r.lock.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-r.done
+	}
+
+	return nil
+}
+
+// startTokenRefresher starts the background token refresher the first time it is called.
+func (h *HTTPClient) startTokenRefresher() {
+	r := h.refresher
+	if r == nil {
+		return
+	}
+
+	r.startOnce.Do(func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		if r.closed {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		r.cancel = cancel
+
+		go h.runTokenRefresher(ctx)
+	})
+}
+
+// tokenFetched tells the background refresher, if there is one, about a new token.
+func (h *HTTPClient) tokenFetched(token string, issuedAt, expiresAt time.Time) {
+	r := h.refresher
+	if r == nil {
+		return
+	}
+
+	r.setToken(token, issuedAt, expiresAt)
+
+	select {
+	case r.updated <- struct{}{}:
+	default:
+	}
+}
+
+func (h *HTTPClient) runTokenRefresher(ctx context.Context) {
+	r := h.refresher
+	defer close(r.done)
+
+	failures := 0
+
+	for {
+		wait := time.Until(r.refreshAt())
+		if failures > 0 {
+			wait = r.backoff.backoff(failures, nil)
+		}
+
+		t := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-r.updated:
+			t.Stop()
+			failures = 0
+			continue
+		case <-t.C:
+		}
+
+		err := h.refreshTokenInBackground(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			failures++
+
+			if h.logger != nil {
+				h.logger.Warn().
+					Err(err).
+					Int("failures", failures).
+					Msg("athenahealth background token refresh failed")
+			}
+
+			continue
+		}
+
+		failures = 0
+	}
+}
+
+// refreshTokenInBackground fetches a new token unless another process already replaced the one
+// the refresher knows about. The cached token is only adopted if it is a different token that
+// expires after the current refresh point: cachers like Redis report the expiry of the same token
+// slightly differently on every read, so a later expiry alone doesn't mean it was replaced.
+func (h *HTTPClient) refreshTokenInBackground(ctx context.Context) error {
+	r := h.refresher
+
+	_, err := h.tokenFlight.do(ctx, tokenFlightKey, func(ctx context.Context) (string, error) {
+		unlock, err := h.lockRefresh(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+
+		if getter, ok := h.tokenCacher.(tokenExpiryGetter); ok {
+			token, expiresAt, err := getter.GetWithExpiry(ctx)
+			if err == nil && r.replacedBy(token, expiresAt) {
+				r.setToken(token, time.Now(), expiresAt)
+				return token, nil
+			}
+		}
+
+		return h.provideToken(ctx)
+	})
+
+	return err
+}
+
+// refreshAt returns when the current token should be replaced. It is now if the refresher doesn't
+// know about a token yet.
+func (r *tokenRefresher) refreshAt() time.Time {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.expiresAt.IsZero() {
+		return time.Now()
+	}
+
+	lifetime := r.expiresAt.Sub(r.issuedAt)
+	wait := time.Duration(float64(lifetime) * r.fraction)
+
+	if wait < r.minInterval {
+		wait = r.minInterval
+	}
+
+	return r.issuedAt.Add(wait)
+}
+
+// replacedBy reports whether token, a cached token that expires at expiresAt, replaced the one the
+// refresher knows about.
+func (r *tokenRefresher) replacedBy(token string, expiresAt time.Time) bool {
+	refreshAt := r.refreshAt()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return token != r.token && expiresAt.After(refreshAt)
+}
+
+func (r *tokenRefresher) setToken(token string, issuedAt, expiresAt time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.token = token
+	r.issuedAt = issuedAt
+	r.expiresAt = expiresAt
+}
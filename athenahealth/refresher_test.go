@@ -0,0 +1,188 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/asatish/go-athenahealth/athenahealth/tokencacher"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// testShortLivedTokenProvider provides tokens that are cached for lifetime. The first failures
+// calls fail.
+type testShortLivedTokenProvider struct {
+	lifetime time.Duration
+	failures int32
+	calls    int32
+}
+
+func (t *testShortLivedTokenProvider) Provide(ctx context.Context) (string, time.Time, error) {
+	calls := atomic.AddInt32(&t.calls, 1)
+	if calls <= t.failures {
+		return "", time.Time{}, errors.New("token endpoint unavailable")
+	}
+
+	// HTTPClient caches tokens for a minute less than their expiry.
+	return testToken, time.Now().Add(time.Minute + t.lifetime), nil
+}
+
+func testRefreshingClient(tokenProvider TokenProvider) (*HTTPClient, func()) {
+	athenaClient, ts := testClient(nil)
+
+	athenaClient.
+		WithTokenProvider(tokenProvider).
+		WithTokenCacher(tokencacher.NewDefault()).
+		WithBackgroundTokenRefresh(0.5)
+
+	athenaClient.refresher.minInterval = 0
+	athenaClient.refresher.backoff = &RetryPolicy{
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+
+	return athenaClient, ts.Close
+}
+
+func TestHTTPClient_WithBackgroundTokenRefresh(t *testing.T) {
+	assert := assert.New(t)
+
+	tokenProvider := &testShortLivedTokenProvider{
+		lifetime: 100 * time.Millisecond,
+	}
+
+	athenaClient, done := testRefreshingClient(tokenProvider)
+	defer done()
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	// The token is refreshed halfway through its lifetime, without any requests.
+	time.Sleep(275 * time.Millisecond)
+
+	assert.NoError(athenaClient.Close())
+
+	calls := atomic.LoadInt32(&tokenProvider.calls)
+	assert.True(calls >= 4, "calls: %d", calls)
+
+	// The refresher is stopped.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(calls, atomic.LoadInt32(&tokenProvider.calls))
+
+	// Closing again is a no-op.
+	assert.NoError(athenaClient.Close())
+}
+
+func TestHTTPClient_WithBackgroundTokenRefresh_backoff(t *testing.T) {
+	assert := assert.New(t)
+
+	tokenProvider := &testShortLivedTokenProvider{
+		lifetime: time.Hour,
+		failures: 3,
+	}
+
+	athenaClient, done := testRefreshingClient(tokenProvider)
+	defer done()
+	defer athenaClient.Close()
+
+	athenaClient.startTokenRefresher()
+
+	// The refresher fetches the first token itself and retries until the provider recovers.
+	assert.Eventually(func() bool {
+		token, err := athenaClient.tokenCacher.Get(context.Background())
+		return err == nil && token == testToken
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(int32(4), atomic.LoadInt32(&tokenProvider.calls))
+}
+
+func TestHTTPClient_WithBackgroundTokenRefresh_cached(t *testing.T) {
+	assert := assert.New(t)
+
+	tokenProvider := &testShortLivedTokenProvider{
+		lifetime: time.Hour,
+	}
+
+	athenaClient, done := testRefreshingClient(tokenProvider)
+	defer done()
+
+	// Another process already cached a token.
+	err := athenaClient.tokenCacher.Set(context.Background(), "cached-token", time.Now().Add(time.Hour))
+	assert.NoError(err)
+
+	athenaClient.startTokenRefresher()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(athenaClient.Close())
+	assert.Zero(atomic.LoadInt32(&tokenProvider.calls))
+}
+
+// testRotatingTokenProvider provides a different token every time, each cached for lifetime.
+type testRotatingTokenProvider struct {
+	lifetime time.Duration
+	calls    int32
+}
+
+func (t *testRotatingTokenProvider) Provide(ctx context.Context) (string, time.Time, error) {
+	calls := atomic.AddInt32(&t.calls, 1)
+
+	return fmt.Sprintf("token-%d", calls), time.Now().Add(time.Minute + t.lifetime), nil
+}
+
+func TestHTTPClient_WithBackgroundTokenRefresh_redis(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	tokenProvider := &testRotatingTokenProvider{
+		lifetime: 2 * time.Second,
+	}
+
+	athenaClient, done := testRefreshingClient(tokenProvider)
+	defer done()
+
+	// Redis reports the expiry of the same token a little later on every read, which mustn't be
+	// mistaken for another process replacing it.
+	athenaClient.WithTokenCacher(tokencacher.NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), ""))
+
+	_, err = athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	time.Sleep(2500 * time.Millisecond)
+
+	assert.NoError(athenaClient.Close())
+
+	calls := atomic.LoadInt32(&tokenProvider.calls)
+	assert.True(calls >= 3, "calls: %d", calls)
+
+	token, err := athenaClient.tokenCacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal(fmt.Sprintf("token-%d", calls), token)
+}
+
+func TestHTTPClient_Close_notStarted(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	assert.NoError(athenaClient.Close())
+
+	athenaClient.WithBackgroundTokenRefresh(0.5)
+	assert.NoError(athenaClient.Close())
+
+	// A closed client doesn't start a refresher.
+	athenaClient.startTokenRefresher()
+	assert.Nil(athenaClient.refresher.cancel)
+}
@@ -0,0 +1,165 @@
+package athenahealth
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy describes if and how a failed request is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// BaseBackoff is the wait before the first retry. It doubles with every subsequent retry.
+	BaseBackoff time.Duration
+
+	// MaxBackoff caps the exponential backoff.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction (0-1) of each backoff that is randomized to spread out retries.
+	Jitter float64
+
+	// RetryableStatusCodes are the HTTP status codes that trigger a retry. Transport errors
+	// (e.g. connection resets) are always retried.
+	RetryableStatusCodes []int
+
+	// RetryableMethods are the HTTP methods that may be retried.
+	RetryableMethods []string
+
+	// RespectRetryAfter waits for the duration in the response's Retry-After header instead of
+	// the computed backoff when it is present.
+	RespectRetryAfter bool
+}
+
+// DefaultRetryPolicy returns a policy that retries idempotent requests up to 3 times on
+// transport errors, 429s and 5xx responses from gateways and overloaded servers.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 250 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		Jitter:      0.5,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableMethods: []string{
+			http.MethodGet,
+			http.MethodPut,
+			http.MethodDelete,
+		},
+		RespectRetryAfter: true,
+	}
+}
+
+// shouldRetry reports whether the attempt that produced res and err should be retried.
+func (r *RetryPolicy) shouldRetry(method string, attempt int, res *http.Response, err error) bool {
+	if r == nil || attempt >= r.MaxAttempts {
+		return false
+	}
+
+	methodRetryable := false
+	for _, m := range r.RetryableMethods {
+		if strings.EqualFold(m, method) {
+			methodRetryable = true
+			break
+		}
+	}
+
+	if !methodRetryable {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	for _, code := range r.RetryableStatusCodes {
+		if res.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns how long to wait before the next attempt.
+func (r *RetryPolicy) backoff(attempt int, res *http.Response) time.Duration {
+	if r.RespectRetryAfter && res != nil {
+		if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			return retryAfter
+		}
+	}
+
+	d := r.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+
+		if r.MaxBackoff > 0 && d >= r.MaxBackoff {
+			break
+		}
+	}
+
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+
+	if r.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * r.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a number of seconds or an
+// HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if len(v) == 0 {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(v)
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+
+	d := t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+
+	return d, true
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package athenahealth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	policy := DefaultRetryPolicy()
+
+	res := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	assert.True(policy.shouldRetry(http.MethodGet, 1, res, nil))
+	assert.True(policy.shouldRetry(http.MethodGet, 1, nil, errors.New("connection reset")))
+	assert.False(policy.shouldRetry(http.MethodGet, policy.MaxAttempts, res, nil))
+	assert.False(policy.shouldRetry(http.MethodPost, 1, res, nil))
+	assert.False(policy.shouldRetry(http.MethodGet, 1, &http.Response{StatusCode: http.StatusBadRequest}, nil))
+
+	var nilPolicy *RetryPolicy
+	assert.False(nilPolicy.shouldRetry(http.MethodGet, 1, res, nil))
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	assert := assert.New(t)
+
+	policy := &RetryPolicy{
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+
+	assert.Equal(100*time.Millisecond, policy.backoff(1, nil))
+	assert.Equal(200*time.Millisecond, policy.backoff(2, nil))
+	assert.Equal(400*time.Millisecond, policy.backoff(3, nil))
+	assert.Equal(time.Second, policy.backoff(10, nil))
+	assert.Equal(time.Second, policy.backoff(100, nil))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := policy.backoff(2, nil)
+		assert.True(d > 100*time.Millisecond && d <= 200*time.Millisecond)
+	}
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", "3")
+
+	policy.RespectRetryAfter = true
+	assert.Equal(3*time.Second, policy.backoff(1, res))
+
+	policy.RespectRetryAfter = false
+	assert.NotEqual(3*time.Second, policy.backoff(1, res))
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter("5", now)
+	assert.True(ok)
+	assert.Equal(5*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now)
+	assert.True(ok)
+	assert.Equal(10*time.Second, d)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(ok)
+
+	_, ok = parseRetryAfter("-1", now)
+	assert.False(ok)
+}
@@ -0,0 +1,59 @@
+package athenahealth
+
+import (
+	"context"
+	"fmt"
+)
+
+type practiceIDKey struct{}
+
+// WithPracticeID returns a copy of ctx whose requests are made for practiceID instead of the
+// client's practice.
+func WithPracticeID(ctx context.Context, practiceID string) context.Context {
+	return context.WithValue(ctx, practiceIDKey{}, practiceID)
+}
+
+// PracticeIDFromContext returns the practice ID set with WithPracticeID, if there is one.
+func PracticeIDFromContext(ctx context.Context) (string, bool) {
+	practiceID, ok := ctx.Value(practiceIDKey{}).(string)
+	if !ok || len(practiceID) == 0 {
+		return "", false
+	}
+
+	return practiceID, true
+}
+
+// Practice returns a client that makes requests for practiceID. It shares h's token provider,
+// token cacher, rate limiter, quota tracker, stats and background refresher, so one OAuth client
+// can serve many practices with a single token and rate limit. Configure h before calling
+// Practice: options set on the returned client only apply to it.
+func (h *HTTPClient) Practice(practiceID string) *HTTPClient {
+	c := *h
+
+	// Clip the middleware so that appending to one client's chain can't write into another's.
+	c.middleware = h.middleware[:len(h.middleware):len(h.middleware)]
+
+	c.practiceID = practiceID
+	c.setBaseURL()
+
+	return &c
+}
+
+// requestPracticeID returns the practice a request is made for: the one set with WithPracticeID,
+// or the client's.
+func (h *HTTPClient) requestPracticeID(ctx context.Context) string {
+	if practiceID, ok := PracticeIDFromContext(ctx); ok {
+		return practiceID
+	}
+
+	return h.practiceID
+}
+
+// practiceBaseURL returns the base URL of requests for practiceID.
+func (h *HTTPClient) practiceBaseURL(practiceID string) string {
+	if practiceID == h.practiceID {
+		return h.baseURL
+	}
+
+	return fmt.Sprintf("%s%s", h.apiBaseURL(), practiceID)
+}
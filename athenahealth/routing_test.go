@@ -0,0 +1,172 @@
+package athenahealth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asatish/go-athenahealth/athenahealth/stats"
+	"github.com/asatish/go-athenahealth/athenahealth/tokencacher"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTokenProvider struct {
+	calls int32
+}
+
+func (c *countingTokenProvider) Provide(ctx context.Context) (string, time.Time, error) {
+	atomic.AddInt32(&c.calls, 1)
+
+	return testToken, time.Now().Add(time.Hour), nil
+}
+
+func TestHTTPClient_Practice(t *testing.T) {
+	assert := assert.New(t)
+
+	var lock sync.Mutex
+	var paths []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		paths = append(paths, r.URL.Path)
+		lock.Unlock()
+
+		w.Write([]byte(`[{"patientid": "1"}]`))
+	}))
+	defer ts.Close()
+
+	provider := &countingTokenProvider{}
+
+	var observations []*stats.Observation
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	athenaClient := NewHTTPClient(ts.Client(), "1", "", "").
+		WithBaseURL(ts.URL).
+		WithTokenProvider(provider).
+		WithTokenCacher(tokencacher.NewDefault()).
+		WithLogger(&logger).
+		WithStats(stats.ObserverFunc(func(o *stats.Observation) error {
+			observations = append(observations, o)
+			return nil
+		}))
+
+	_, err := athenaClient.GetPatient(context.Background(), "1", nil)
+	assert.NoError(err)
+
+	_, err = athenaClient.Practice("195900").GetPatient(context.Background(), "1", nil)
+	assert.NoError(err)
+
+	_, err = athenaClient.GetPatient(WithPracticeID(context.Background(), "195901"), "1", nil)
+	assert.NoError(err)
+
+	// The practice in the context wins over the client's.
+	_, err = athenaClient.Practice("195900").GetPatient(WithPracticeID(context.Background(), "195902"), "1", nil)
+	assert.NoError(err)
+
+	assert.Equal([]string{"/1/patients/1", "/195900/patients/1", "/195901/patients/1", "/195902/patients/1"}, paths)
+
+	// All of the practices share the client's token.
+	assert.Equal(int32(1), atomic.LoadInt32(&provider.calls))
+
+	if assert.Len(observations, 4) {
+		assert.Equal("1", observations[0].PracticeID)
+		assert.Equal("195900", observations[1].PracticeID)
+		assert.Equal("195901", observations[2].PracticeID)
+		assert.Equal("195902", observations[3].PracticeID)
+	}
+
+	assert.Contains(buf.String(), `"practiceID":"195901"`)
+
+	// The parent client is unchanged.
+	assert.Equal("1", athenaClient.practiceID)
+}
+
+func TestHTTPClient_Practice_middleware(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []string
+
+	named := func(name string) Middleware {
+		return func(next RoundTrip) RoundTrip {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next(req)
+			}
+		}
+	}
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	athenaClient.WithBaseURL(ts.URL)
+
+	// Leave spare capacity in the parent's middleware so that an unclipped copy would share it.
+	athenaClient.middleware = make([]Middleware, 0, 4)
+	athenaClient.WithMiddleware(named("parent"))
+
+	a := athenaClient.Practice("195900").WithMiddleware(named("a"))
+	b := athenaClient.Practice("195901").WithMiddleware(named("b"))
+
+	_, err := a.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+	assert.Equal([]string{"parent", "a"}, calls)
+
+	calls = nil
+
+	_, err = b.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+	assert.Equal([]string{"parent", "b"}, calls)
+
+	assert.Len(athenaClient.middleware, 1)
+}
+
+type testPracticeStats struct {
+	testStats
+	practiceIDs []string
+}
+
+func (t *testPracticeStats) PracticeRequest(practiceID, method, path string) error {
+	t.practiceIDs = append(t.practiceIDs, practiceID)
+
+	return nil
+}
+
+func TestHTTPClient_Practice_stats(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, ts := testClient(nil)
+	defer ts.Close()
+
+	practiceStats := &testPracticeStats{}
+	athenaClient.WithBaseURL(ts.URL).WithStats(practiceStats)
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	_, err = athenaClient.Practice("195900").request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	assert.Equal([]string{testPracticeID, "195900"}, practiceStats.practiceIDs)
+}
+
+func TestPracticeIDFromContext(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ok := PracticeIDFromContext(context.Background())
+	assert.False(ok)
+
+	_, ok = PracticeIDFromContext(WithPracticeID(context.Background(), ""))
+	assert.False(ok)
+
+	practiceID, ok := PracticeIDFromContext(WithPracticeID(context.Background(), "195900"))
+	assert.True(ok)
+	assert.Equal("195900", practiceID)
+}
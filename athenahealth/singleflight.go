@@ -0,0 +1,73 @@
+package athenahealth
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// flightTimeout is the deadline of a deduplicated call. It isn't tied to any one caller's context,
+// so it needs a deadline of its own.
+const flightTimeout = defaultRequestTimeout
+
+// flightGroup deduplicates concurrent calls with the same key so that only one of them is in
+// flight at a time. Callers that arrive while a call is in flight wait for and share its result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+
+	val string
+	err error
+}
+
+// do runs fn unless a call with the same key is already in flight, in which case it waits for
+// that call's result. fn runs under its own context with a deadline of flightTimeout, so that one
+// caller giving up doesn't fail the call for the others. Each caller, including the one that
+// started the call, stops waiting early if its ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (string, error)) (string, error) {
+	g.mu.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+
+	c, ok := g.calls[key]
+	if !ok {
+		c = &flightCall{
+			done: make(chan struct{}),
+		}
+		g.calls[key] = c
+
+		go g.run(ctx, key, c, fn)
+	}
+
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(callerCtx context.Context, key string, c *flightCall, fn func(context.Context) (string, error)) {
+	// Keep the caller's span so that the call is still traced under the request that started it.
+	ctx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(callerCtx))
+
+	ctx, cancel := context.WithTimeout(ctx, flightTimeout)
+	defer cancel()
+
+	c.val, c.err = fn(ctx)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	close(c.done)
+}
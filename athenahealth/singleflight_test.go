@@ -0,0 +1,124 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightGroup_do(t *testing.T) {
+	assert := assert.New(t)
+
+	g := &flightGroup{}
+
+	var calls int32
+	release := make(chan struct{})
+
+	fn := func(context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+
+		return "foo", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			results[i], _ = g.do(context.Background(), "key", fn)
+		}(i)
+	}
+
+	// Give the goroutines time to join the in-flight call.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+	for _, r := range results {
+		assert.Equal("foo", r)
+	}
+
+	// Once the call completes, the next one runs fn again.
+	val, err := g.do(context.Background(), "key", func(context.Context) (string, error) {
+		return "bar", nil
+	})
+	assert.Equal("bar", val)
+	assert.NoError(err)
+}
+
+func TestFlightGroup_do_context(t *testing.T) {
+	assert := assert.New(t)
+
+	g := &flightGroup{}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	go g.do(context.Background(), "key", func(context.Context) (string, error) {
+		<-release
+		return "foo", nil
+	})
+
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := g.do(ctx, "key", func(context.Context) (string, error) {
+		return "bar", nil
+	})
+
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestFlightGroup_do_first_caller_canceled(t *testing.T) {
+	assert := assert.New(t)
+
+	g := &flightGroup{}
+
+	release := make(chan struct{})
+
+	var fnErr error
+	fn := func(ctx context.Context) (string, error) {
+		<-release
+		fnErr = ctx.Err()
+
+		return "foo", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	firstErr := make(chan error)
+	go func() {
+		_, err := g.do(ctx, "key", fn)
+		firstErr <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	result := make(chan string)
+	go func() {
+		val, _ := g.do(context.Background(), "key", fn)
+		result <- val
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	// The first caller gives up, but the call keeps running for the second one.
+	cancel()
+	assert.True(errors.Is(<-firstErr, context.Canceled))
+
+	close(release)
+	assert.Equal("foo", <-result)
+	assert.NoError(fnErr)
+}
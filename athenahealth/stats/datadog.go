@@ -20,12 +20,24 @@ func NewDatadog(client statsd.ClientInterface) *Datadog {
 }
 
 func (d *Datadog) Request(method, path string) error {
-	path = cleanPath(path)
+	return d.PracticeRequest("", method, path)
+}
+
+// PracticeRequest counts a request like Request does, tagged with the practice it is made for. The
+// practice tag is left out if practiceID is empty.
+func (d *Datadog) PracticeRequest(practiceID, method, path string) error {
+	path = CleanPath(path)
 
-	return d.client.Incr("athenahealth.requests", []string{
+	tags := []string{
 		"http_method:" + method,
 		"http_path:" + path,
-	}, 1.0)
+	}
+
+	if len(practiceID) > 0 {
+		tags = append(tags, "practice_id:"+practiceID)
+	}
+
+	return d.client.Incr("athenahealth.requests", tags, 1.0)
 }
 
 func (d *Datadog) ResponseSuccess() error {
@@ -36,7 +48,42 @@ func (d *Datadog) ResponseError() error {
 	return d.client.Incr("athenahealth.responses.error", []string{}, 1.0)
 }
 
-func cleanPath(path string) string {
+// Observe emits timing and size metrics for a completed request, tagged with its practice ID,
+// method, path and status.
+func (d *Datadog) Observe(o *Observation) error {
+	tags := []string{
+		"practice_id:" + o.PracticeID,
+		"http_method:" + o.Method,
+		"http_path:" + CleanPath(o.Path),
+		"http_status:" + o.Status(),
+	}
+
+	err := d.client.Timing("athenahealth.request.duration", o.Duration, tags, 1.0)
+	if err != nil {
+		return err
+	}
+
+	err = d.client.Timing("athenahealth.request.rate_limit_wait", o.RateLimitWait, tags, 1.0)
+	if err != nil {
+		return err
+	}
+
+	err = d.client.Histogram("athenahealth.request.retries", float64(o.Retries), tags, 1.0)
+	if err != nil {
+		return err
+	}
+
+	err = d.client.Histogram("athenahealth.request.bytes", float64(o.RequestBytes), tags, 1.0)
+	if err != nil {
+		return err
+	}
+
+	return d.client.Histogram("athenahealth.response.bytes", float64(o.ResponseBytes), tags, 1.0)
+}
+
+// CleanPath strips the query string from path and replaces the IDs in it with ":id:" so that
+// requests to the same endpoint share a name, e.g. "/patients/123" becomes "/patients/:id:".
+func CleanPath(path string) string {
 	u, err := url.Parse(path)
 	if err != nil {
 		return ""
@@ -2,6 +2,7 @@ package stats
 
 import (
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +17,25 @@ func (m *mockClient) Incr(name string, tags []string, rate float64) error {
 	return m.incrFn(name, tags, rate)
 }
 
+type mockTimingClient struct {
+	statsd.ClientInterface
+	timings    map[string]time.Duration
+	histograms map[string]float64
+	tags       [][]string
+}
+
+func (m *mockTimingClient) Timing(name string, value time.Duration, tags []string, rate float64) error {
+	m.timings[name] = value
+	m.tags = append(m.tags, tags)
+	return nil
+}
+
+func (m *mockTimingClient) Histogram(name string, value float64, tags []string, rate float64) error {
+	m.histograms[name] = value
+	m.tags = append(m.tags, tags)
+	return nil
+}
+
 func TestDatadog_Request(t *testing.T) {
 	assert := assert.New(t)
 
@@ -33,11 +53,66 @@ func TestDatadog_Request(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestDatadog_PracticeRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockClient{}
+
+	var tags []string
+	client.incrFn = func(name string, t []string, rate float64) error {
+		tags = t
+		return nil
+	}
+
+	datadog := NewDatadog(client)
+
+	assert.NoError(datadog.PracticeRequest("195900", "get", "/patients/123"))
+	assert.Equal([]string{"http_method:get", "http_path:/patients/:id:", "practice_id:195900"}, tags)
+
+	// Requests that aren't made for a practice aren't tagged with one.
+	assert.NoError(datadog.PracticeRequest("", "get", "/1/practiceinfo"))
+	assert.Equal([]string{"http_method:get", "http_path:/:id:/practiceinfo"}, tags)
+}
+
+func TestDatadog_Observe(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockTimingClient{
+		timings:    map[string]time.Duration{},
+		histograms: map[string]float64{},
+	}
+
+	datadog := NewDatadog(client)
+
+	err := datadog.Observe(&Observation{
+		PracticeID:    "195900",
+		Method:        "GET",
+		Path:          "/patients/123",
+		StatusCode:    200,
+		Duration:      250 * time.Millisecond,
+		RequestBytes:  10,
+		ResponseBytes: 2048,
+		Retries:       1,
+		RateLimitWait: 50 * time.Millisecond,
+	})
+	assert.NoError(err)
+
+	assert.Equal(250*time.Millisecond, client.timings["athenahealth.request.duration"])
+	assert.Equal(50*time.Millisecond, client.timings["athenahealth.request.rate_limit_wait"])
+	assert.Equal(1.0, client.histograms["athenahealth.request.retries"])
+	assert.Equal(10.0, client.histograms["athenahealth.request.bytes"])
+	assert.Equal(2048.0, client.histograms["athenahealth.response.bytes"])
+
+	for _, tags := range client.tags {
+		assert.Equal([]string{"practice_id:195900", "http_method:GET", "http_path:/patients/:id:", "http_status:200"}, tags)
+	}
+}
+
 func TestRemoveIDsFromPath(t *testing.T) {
 	assert := assert.New(t)
 
-	assert.Equal("/patients/:id:", cleanPath("/patients/123"))
-	assert.Equal("/patients/:id:", cleanPath("/patients/123?foo=bar"))
-	assert.Equal("/patients/:id:/foo/:id:", cleanPath("/patients/123/foo/1"))
-	assert.Equal("/patients/:id:/foo/:id:/", cleanPath("/patients/123/foo/1/"))
+	assert.Equal("/patients/:id:", CleanPath("/patients/123"))
+	assert.Equal("/patients/:id:", CleanPath("/patients/123?foo=bar"))
+	assert.Equal("/patients/:id:/foo/:id:", CleanPath("/patients/123/foo/1"))
+	assert.Equal("/patients/:id:/foo/:id:/", CleanPath("/patients/123/foo/1/"))
 }
@@ -18,3 +18,7 @@ func (d *Default) ResponseSuccess() error {
 func (d *Default) ResponseError() error {
 	return nil
 }
+
+func (d *Default) Observe(o *Observation) error {
+	return nil
+}
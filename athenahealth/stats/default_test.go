@@ -29,3 +29,11 @@ func TestDefault_ResponseError(t *testing.T) {
 	err := stats.ResponseError()
 	assert.NoError(err)
 }
+
+func TestDefault_Observe(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := NewDefault()
+	err := stats.Observe(&Observation{})
+	assert.NoError(err)
+}
@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"strconv"
+	"time"
+)
+
+// Observation describes a completed request to athenahealth, including all of its retries.
+type Observation struct {
+	// PracticeID is the practice the request was made for.
+	PracticeID string
+
+	// Method is the request's HTTP method.
+	Method string
+
+	// Path is the templated request path, as returned by CleanPath.
+	Path string
+
+	// StatusCode is the status code of the final response, or 0 if no response was received.
+	StatusCode int
+
+	// Duration is the time from the start of the request until its response was read, including
+	// rate limit waits and retries.
+	Duration time.Duration
+
+	// RequestBytes is the size of the request body.
+	RequestBytes int
+
+	// ResponseBytes is the size of the final response body.
+	ResponseBytes int
+
+	// Retries is the number of attempts made after the first one.
+	Retries int
+
+	// RateLimitWait is the time spent waiting for the rate limiter.
+	RateLimitWait time.Duration
+
+	// Err is the error returned for the request, if any.
+	Err error
+}
+
+// Status returns the observation's status code as a string, or "error" if no response was
+// received.
+func (o *Observation) Status() string {
+	if o.StatusCode == 0 {
+		return "error"
+	}
+
+	return strconv.Itoa(o.StatusCode)
+}
+
+// ObserverFunc adapts a function that only handles observations to the Stats interface. Its
+// Request, ResponseSuccess and ResponseError methods do nothing.
+type ObserverFunc func(*Observation) error
+
+func (f ObserverFunc) Request(method, path string) error {
+	return nil
+}
+
+func (f ObserverFunc) ResponseSuccess() error {
+	return nil
+}
+
+func (f ObserverFunc) ResponseError() error {
+	return nil
+}
+
+func (f ObserverFunc) Observe(o *Observation) error {
+	return f(o)
+}
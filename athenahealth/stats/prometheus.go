@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus records athenahealth API metrics as Prometheus counters and histograms. It is a
+// prometheus.Collector, so register it with a registry to expose the metrics:
+//
+//	p := stats.NewPrometheus()
+//	prometheus.MustRegister(p)
+type Prometheus struct {
+	requests         *prometheus.CounterVec
+	practiceRequests *prometheus.CounterVec
+	responses        *prometheus.CounterVec
+	duration         *prometheus.HistogramVec
+	rateLimitWait    *prometheus.HistogramVec
+	retries          *prometheus.HistogramVec
+	requestBytes     *prometheus.HistogramVec
+	responseBytes    *prometheus.HistogramVec
+}
+
+func NewPrometheus() *Prometheus {
+	labels := []string{"method", "path"}
+	observationLabels := []string{"practice_id", "method", "path"}
+	sizeBuckets := prometheus.ExponentialBuckets(64, 4, 8)
+
+	return &Prometheus{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "athenahealth_requests_total",
+			Help: "Requests sent to the athenahealth API, including retries.",
+		}, labels),
+		practiceRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "athenahealth_practice_requests_total",
+			Help: "Requests sent to the athenahealth API for a practice, including retries.",
+		}, observationLabels),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "athenahealth_responses_total",
+			Help: "Responses received from the athenahealth API by result.",
+		}, []string{"result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "athenahealth_request_duration_seconds",
+			Help:    "Duration of athenahealth API requests, including rate limit waits and retries.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"practice_id", "method", "path", "status"}),
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "athenahealth_request_rate_limit_wait_seconds",
+			Help:    "Time athenahealth API requests spent waiting for the rate limiter.",
+			Buckets: prometheus.DefBuckets,
+		}, observationLabels),
+		retries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "athenahealth_request_retries",
+			Help:    "Retries made by athenahealth API requests.",
+			Buckets: []float64{0, 1, 2, 3, 5, 10},
+		}, observationLabels),
+		requestBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "athenahealth_request_size_bytes",
+			Help:    "Size of athenahealth API request bodies.",
+			Buckets: sizeBuckets,
+		}, observationLabels),
+		responseBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "athenahealth_response_size_bytes",
+			Help:    "Size of athenahealth API response bodies.",
+			Buckets: sizeBuckets,
+		}, observationLabels),
+	}
+}
+
+func (p *Prometheus) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		p.requests,
+		p.practiceRequests,
+		p.responses,
+		p.duration,
+		p.rateLimitWait,
+		p.retries,
+		p.requestBytes,
+		p.responseBytes,
+	}
+}
+
+func (p *Prometheus) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range p.collectors() {
+		c.Describe(ch)
+	}
+}
+
+func (p *Prometheus) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range p.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (p *Prometheus) Request(method, path string) error {
+	return p.PracticeRequest("", method, path)
+}
+
+// PracticeRequest counts a request like Request does. Requests made for a practice are also counted
+// in athenahealth_practice_requests_total, labeled with the practice. athenahealth_requests_total
+// keeps its labels so that it still counts every request.
+func (p *Prometheus) PracticeRequest(practiceID, method, path string) error {
+	path = CleanPath(path)
+
+	p.requests.WithLabelValues(method, path).Inc()
+
+	if len(practiceID) > 0 {
+		p.practiceRequests.WithLabelValues(practiceID, method, path).Inc()
+	}
+
+	return nil
+}
+
+func (p *Prometheus) ResponseSuccess() error {
+	p.responses.WithLabelValues("success").Inc()
+
+	return nil
+}
+
+func (p *Prometheus) ResponseError() error {
+	p.responses.WithLabelValues("error").Inc()
+
+	return nil
+}
+
+func (p *Prometheus) Observe(o *Observation) error {
+	path := CleanPath(o.Path)
+
+	p.duration.WithLabelValues(o.PracticeID, o.Method, path, o.Status()).Observe(o.Duration.Seconds())
+	p.rateLimitWait.WithLabelValues(o.PracticeID, o.Method, path).Observe(o.RateLimitWait.Seconds())
+	p.retries.WithLabelValues(o.PracticeID, o.Method, path).Observe(float64(o.Retries))
+	p.requestBytes.WithLabelValues(o.PracticeID, o.Method, path).Observe(float64(o.RequestBytes))
+	p.responseBytes.WithLabelValues(o.PracticeID, o.Method, path).Observe(float64(o.ResponseBytes))
+
+	return nil
+}
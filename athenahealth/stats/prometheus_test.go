@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheus_Request(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPrometheus()
+
+	assert.NoError(p.Request("GET", "/patients/123?firstname=John"))
+	assert.NoError(p.Request("GET", "/patients/456"))
+
+	assert.Equal(2.0, testutil.ToFloat64(p.requests.WithLabelValues("GET", "/patients/:id:")))
+}
+
+func TestPrometheus_PracticeRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPrometheus()
+
+	assert.NoError(p.PracticeRequest("195900", "GET", "/patients/123"))
+	assert.NoError(p.PracticeRequest("195900", "GET", "/patients/456"))
+	assert.NoError(p.PracticeRequest("", "GET", "/patients/789"))
+
+	// Every request is counted in the total, but only the ones made for a practice are labeled
+	// with it.
+	assert.Equal(3.0, testutil.ToFloat64(p.requests.WithLabelValues("GET", "/patients/:id:")))
+	assert.Equal(2.0, testutil.ToFloat64(p.practiceRequests.WithLabelValues("195900", "GET", "/patients/:id:")))
+	assert.Equal(1, testutil.CollectAndCount(p.practiceRequests))
+}
+
+func TestPrometheus_Responses(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPrometheus()
+
+	assert.NoError(p.ResponseSuccess())
+	assert.NoError(p.ResponseSuccess())
+	assert.NoError(p.ResponseError())
+
+	assert.Equal(2.0, testutil.ToFloat64(p.responses.WithLabelValues("success")))
+	assert.Equal(1.0, testutil.ToFloat64(p.responses.WithLabelValues("error")))
+}
+
+func TestPrometheus_Observe(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPrometheus()
+
+	err := p.Observe(&Observation{
+		Method:        "GET",
+		Path:          "/patients/123",
+		StatusCode:    200,
+		Duration:      250 * time.Millisecond,
+		ResponseBytes: 2048,
+		Retries:       1,
+	})
+	assert.NoError(err)
+
+	err = p.Observe(&Observation{
+		Method: "GET",
+		Path:   "/patients/123",
+		Err:    errors.New("connection reset"),
+	})
+	assert.NoError(err)
+
+	// One duration series per status, one series each for the other histograms.
+	assert.Equal(6, testutil.CollectAndCount(p))
+
+	err = p.Observe(&Observation{
+		PracticeID: "195900",
+		Method:     "GET",
+		Path:       "/patients/123",
+		StatusCode: 200,
+	})
+	assert.NoError(err)
+
+	// Every histogram has a series for the other practice.
+	assert.Equal(11, testutil.CollectAndCount(p))
+}
+
+func TestPrometheus_register(t *testing.T) {
+	assert := assert.New(t)
+
+	registry := prometheus.NewRegistry()
+
+	p := NewPrometheus()
+	assert.NoError(registry.Register(p))
+
+	p.Request("GET", "/patients")
+
+	families, err := registry.Gather()
+	assert.NoError(err)
+	assert.Len(families, 1)
+	assert.Equal("athenahealth_requests_total", families[0].GetName())
+}
@@ -18,18 +18,25 @@ func NewDefault() *Default {
 }
 
 func (d *Default) Get(ctx context.Context) (string, error) {
+	token, _, err := d.GetWithExpiry(ctx)
+
+	return token, err
+}
+
+// GetWithExpiry returns the token and when it expires.
+func (d *Default) GetWithExpiry(ctx context.Context) (string, time.Time, error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
 	if len(d.token) == 0 {
-		return "", ErrTokenNotExist
+		return "", time.Time{}, ErrTokenNotExist
 	}
 
 	if time.Now().After(d.expiresAt) {
-		return "", ErrTokenExpired
+		return "", time.Time{}, ErrTokenExpired
 	}
 
-	return d.token, nil
+	return d.token, d.expiresAt, nil
 }
 
 func (d *Default) Set(ctx context.Context, token string, expiresAt time.Time) error {
@@ -41,3 +48,13 @@ func (d *Default) Set(ctx context.Context, token string, expiresAt time.Time) er
 
 	return nil
 }
+
+func (d *Default) Invalidate(ctx context.Context) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.token = ""
+	d.expiresAt = time.Time{}
+
+	return nil
+}
@@ -22,6 +22,20 @@ func TestDefault_Get(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestDefault_GetWithExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher := NewDefault()
+	cacher.token = "foo"
+	cacher.expiresAt = time.Now().Add(time.Minute * 1)
+
+	token, expiresAt, err := cacher.GetWithExpiry(context.Background())
+
+	assert.Equal(cacher.token, token)
+	assert.Equal(cacher.expiresAt, expiresAt)
+	assert.NoError(err)
+}
+
 func TestDefault_Get_ErrTokenNotExist(t *testing.T) {
 	assert := assert.New(t)
 
@@ -64,3 +78,19 @@ func TestDefault_Set(t *testing.T) {
 	assert.True(expiresAt.Equal(cacher.expiresAt))
 	assert.NoError(err)
 }
+
+func TestDefault_Invalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher := NewDefault()
+	cacher.token = "foo"
+	cacher.expiresAt = time.Now().Add(time.Minute * 1)
+
+	err := cacher.Invalidate(context.Background())
+	assert.NoError(err)
+
+	token, err := cacher.Get(context.Background())
+
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenNotExist))
+}
@@ -4,3 +4,4 @@ import "errors"
 
 var ErrTokenNotExist = errors.New("token does not exist")
 var ErrTokenExpired = errors.New("token expired")
+var ErrTokenTampered = errors.New("token file failed authentication")
@@ -2,17 +2,29 @@ package tokencacher
 
 import (
 	"context"
+	"crypto/cipher"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
 
+// refreshLockPollInterval is how often LockRefresh checks whether another process released the
+// refresh lock.
+const refreshLockPollInterval = 50 * time.Millisecond
+
+// File caches the token in a file that can be shared by several processes. Reads and writes take
+// an advisory lock on a ".lock" file next to it, and writes replace the file atomically so that a
+// reader never sees a partially written token.
 type File struct {
 	path string
 
+	// aeads encrypt the token file when it is set. The first one is the current key.
+	aeads []cipher.AEAD
+
 	lock sync.Mutex
 }
 
@@ -32,43 +44,74 @@ func NewFile(path string) *File {
 }
 
 func (f *File) Get(ctx context.Context) (string, error) {
+	token, _, err := f.GetWithExpiry(ctx)
+
+	return token, err
+}
+
+// GetWithExpiry returns the token and when it expires.
+func (f *File) GetWithExpiry(ctx context.Context) (string, time.Time, error) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	_, err := os.Stat(f.path)
-	if os.IsNotExist(err) {
-		err = ioutil.WriteFile(f.path, nil, 0600)
-		if err != nil {
-			return "", err
-		}
+	// Reading a file encrypted with a previous key writes it again with the current one.
+	unlock, err := f.lockFile(len(f.aeads) > 1)
+	if err != nil {
+		return "", time.Time{}, err
 	}
+	defer unlock()
 
 	contents, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return "", time.Time{}, ErrTokenNotExist
+	}
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
 	if len(contents) == 0 {
-		return "", ErrTokenNotExist
+		return "", time.Time{}, ErrTokenNotExist
+	}
+
+	if len(f.aeads) > 0 {
+		var rotated bool
+
+		contents, rotated, err = f.open(contents)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+
+		if rotated {
+			err = f.write(contents)
+			if err != nil {
+				return "", time.Time{}, err
+			}
+		}
 	}
 
 	c := &fileCache{}
 	err = json.Unmarshal(contents, c)
 	if err != nil {
-		return "", fmt.Errorf("Error unmarshaling token: %s", err)
+		return "", time.Time{}, fmt.Errorf("Error unmarshaling token: %s", err)
 	}
 
 	if time.Now().After(c.ExpiresAt) {
-		return "", ErrTokenExpired
+		return "", time.Time{}, ErrTokenExpired
 	}
 
-	return c.Token, nil
+	return c.Token, c.ExpiresAt, nil
 }
 
 func (f *File) Set(ctx context.Context, token string, expiresAt time.Time) error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
+	unlock, err := f.lockFile(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	c := &fileCache{
 		Token:     token,
 		ExpiresAt: expiresAt,
@@ -79,10 +122,124 @@ func (f *File) Set(ctx context.Context, token string, expiresAt time.Time) error
 		return err
 	}
 
-	err = ioutil.WriteFile(f.path, b, 0600)
+	return f.write(b)
+}
+
+func (f *File) Invalidate(ctx context.Context) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	unlock, err := f.lockFile(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return writeFileAtomic(f.path, nil)
+}
+
+// LockRefresh takes an exclusive lock on a ".refresh.lock" file next to the token file, waiting
+// until no other process holds it or ctx is done. HTTPClient holds it while fetching a new token
+// so that only one process calls the token provider when the cached token expires.
+func (f *File) LockRefresh(ctx context.Context) (func(), error) {
+	lf, err := os.OpenFile(f.path+".refresh.lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		locked, err := tryFlock(lf)
+		if err != nil {
+			lf.Close()
+			return nil, err
+		}
+
+		if locked {
+			return func() {
+				funlock(lf)
+				lf.Close()
+			}, nil
+		}
+
+		t := time.NewTimer(refreshLockPollInterval)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			lf.Close()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// lockFile takes an advisory lock on the token's lock file. The token file itself can't be locked
+// because writes replace it.
+func (f *File) lockFile(exclusive bool) (func(), error) {
+	lf, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = flock(lf, exclusive)
+	if err != nil {
+		lf.Close()
+		return nil, err
+	}
+
+	return func() {
+		funlock(lf)
+		lf.Close()
+	}, nil
+}
+
+// write writes b to the token file, encrypting it if encryption is enabled.
+func (f *File) write(b []byte) error {
+	if len(f.aeads) > 0 {
+		var err error
+
+		b, err = f.seal(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(f.path, b)
+}
+
+// writeFileAtomic writes b to a temporary file in the same directory as path, then renames it to
+// path, so that readers see either the old or the new contents.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	// Clean up if anything fails before the rename. After it this is a no-op.
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(0600)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return os.Rename(tmp.Name(), path)
 }
@@ -0,0 +1,114 @@
+package tokencacher
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FileKeyEnv is the environment variable KeyFromEnv reads by default.
+const FileKeyEnv = "ATHENA_TOKEN_CACHE_KEY"
+
+// sealedPrefix marks the contents of an encrypted token file.
+var sealedPrefix = []byte("aesgcm:")
+
+// sealedAdditionalData is authenticated along with every sealed token.
+var sealedAdditionalData = []byte("go-athenahealth token")
+
+// KeyFromEnv derives a 32 byte encryption key from the value of the environment variable name, or
+// FileKeyEnv if name is empty.
+func KeyFromEnv(name string) ([]byte, error) {
+	if len(name) == 0 {
+		name = FileKeyEnv
+	}
+
+	v := os.Getenv(name)
+	if len(v) == 0 {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	key := sha256.Sum256([]byte(v))
+
+	return key[:], nil
+}
+
+// WithEncryption encrypts the token file with AES-GCM using key, which must be 16, 24 or 32 bytes
+// long. Files encrypted with one of previousKeys are still read, and are encrypted with key again
+// the next time the token is read, so keys can be rotated without losing the cached token. Files
+// that fail authentication, including plaintext files, are rejected with ErrTokenTampered, which
+// HTTPClient treats like a missing token and replaces.
+func (f *File) WithEncryption(key []byte, previousKeys ...[]byte) *File {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.aeads = nil
+
+	for _, k := range append([][]byte{key}, previousKeys...) {
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			panic(fmt.Sprintf("invalid encryption key: %s", err))
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(fmt.Sprintf("invalid encryption key: %s", err))
+		}
+
+		f.aeads = append(f.aeads, aead)
+	}
+
+	return f
+}
+
+// seal encrypts b with the current key.
+func (f *File) seal(b []byte) ([]byte, error) {
+	aead := f.aeads[0]
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := aead.Seal(nonce, nonce, b, sealedAdditionalData)
+
+	out := make([]byte, len(sealedPrefix)+base64.StdEncoding.EncodedLen(len(sealed)))
+	copy(out, sealedPrefix)
+	base64.StdEncoding.Encode(out[len(sealedPrefix):], sealed)
+
+	return out, nil
+}
+
+// open decrypts contents with the current key or one of the previous keys. rotated is true if a
+// previous key was used.
+func (f *File) open(contents []byte) (b []byte, rotated bool, err error) {
+	if !bytes.HasPrefix(contents, sealedPrefix) {
+		return nil, false, ErrTokenTampered
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(contents[len(sealedPrefix):]))
+	if err != nil {
+		return nil, false, ErrTokenTampered
+	}
+
+	for i, aead := range f.aeads {
+		if len(sealed) < aead.NonceSize() {
+			return nil, false, ErrTokenTampered
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+		b, err := aead.Open(nil, nonce, ciphertext, sealedAdditionalData)
+		if err == nil {
+			return b, i > 0, nil
+		}
+	}
+
+	return nil, false, ErrTokenTampered
+}
@@ -0,0 +1,134 @@
+package tokencacher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = bytes.Repeat([]byte{1}, 32)
+var testPreviousKey = bytes.Repeat([]byte{2}, 32)
+
+func TestFile_WithEncryption(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := ioutil.TempFile("", "go-athenahealth_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
+
+	cacher := NewFile(file.Name()).WithEncryption(testKey)
+
+	err = cacher.Set(context.Background(), "foo", time.Now().Add(time.Minute*1))
+	assert.NoError(err)
+
+	b, _ := ioutil.ReadFile(file.Name())
+	assert.NotContains(string(b), "foo")
+	assert.True(bytes.HasPrefix(b, sealedPrefix))
+
+	token, err := cacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+}
+
+func TestFile_WithEncryption_tampered(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := ioutil.TempFile("", "go-athenahealth_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
+
+	cacher := NewFile(file.Name()).WithEncryption(testKey)
+
+	err = cacher.Set(context.Background(), "foo", time.Now().Add(time.Minute*1))
+	assert.NoError(err)
+
+	b, _ := ioutil.ReadFile(file.Name())
+	b[len(b)-5] ^= 'A' ^ 'B'
+	ioutil.WriteFile(file.Name(), b, 0600)
+
+	token, err := cacher.Get(context.Background())
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenTampered))
+
+	// Plaintext files are rejected too.
+	c, _ := json.Marshal(&fileCache{
+		Token:     "bar",
+		ExpiresAt: time.Now().Add(time.Minute * 1),
+	})
+	ioutil.WriteFile(file.Name(), c, 0600)
+
+	token, err = cacher.Get(context.Background())
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenTampered))
+
+	// So are files encrypted with an unknown key.
+	err = NewFile(file.Name()).WithEncryption(testPreviousKey).Set(context.Background(), "baz", time.Now().Add(time.Minute*1))
+	assert.NoError(err)
+
+	token, err = cacher.Get(context.Background())
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenTampered))
+}
+
+func TestFile_WithEncryption_rotation(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := ioutil.TempFile("", "go-athenahealth_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
+
+	err = NewFile(file.Name()).WithEncryption(testPreviousKey).Set(context.Background(), "foo", time.Now().Add(time.Minute*1))
+	assert.NoError(err)
+
+	cacher := NewFile(file.Name()).WithEncryption(testKey, testPreviousKey)
+
+	token, err := cacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	// The file was encrypted with the new key, so the previous key is no longer needed.
+	token, err = NewFile(file.Name()).WithEncryption(testKey).Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+}
+
+func TestFile_WithEncryption_invalidKey(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Panics(func() {
+		NewFile("token").WithEncryption([]byte("short"))
+	})
+}
+
+func TestKeyFromEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv("GO_ATHENAHEALTH_TEST_KEY", "correct horse battery staple")
+	defer os.Unsetenv("GO_ATHENAHEALTH_TEST_KEY")
+
+	key, err := KeyFromEnv("GO_ATHENAHEALTH_TEST_KEY")
+	assert.NoError(err)
+	assert.Len(key, 32)
+
+	again, _ := KeyFromEnv("GO_ATHENAHEALTH_TEST_KEY")
+	assert.Equal(key, again)
+
+	_, err = KeyFromEnv("GO_ATHENAHEALTH_TEST_KEY_UNSET")
+	assert.Error(err)
+}
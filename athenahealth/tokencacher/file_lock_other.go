@@ -0,0 +1,21 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package tokencacher
+
+import "os"
+
+// File locks are only supported on Unix-like systems. Elsewhere File only guards against
+// concurrent use within the process.
+
+func flock(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func tryFlock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func funlock(f *os.File) error {
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,6 +23,7 @@ func TestFile_Get(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
 
 	c := &fileCache{
 		Token:     "foo",
@@ -45,6 +49,7 @@ func TestFile_Get_ErrTokenNotExist(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
 
 	cacher := NewFile(file.Name())
 
@@ -63,6 +68,7 @@ func TestFile_Get_ErrTokenExpired(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
 
 	c := &fileCache{
 		Token:     "foo",
@@ -89,6 +95,7 @@ func TestFile_Set(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
 
 	cacher := NewFile(file.Name())
 
@@ -105,3 +112,116 @@ func TestFile_Set(t *testing.T) {
 	assert.Equal(token, c.Token)
 	assert.True(expiresAt.Equal(c.ExpiresAt))
 }
+
+func TestFile_Invalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := ioutil.TempFile("", "go-athenahealth_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer os.Remove(file.Name() + ".lock")
+
+	cacher := NewFile(file.Name())
+
+	err = cacher.Set(context.Background(), "foo", time.Now().Add(time.Minute*1))
+	assert.NoError(err)
+
+	err = cacher.Invalidate(context.Background())
+	assert.NoError(err)
+
+	token, err := cacher.Get(context.Background())
+
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenNotExist))
+}
+
+func TestFile_Get_missing(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher := NewFile(filepath.Join(t.TempDir(), "token"))
+
+	token, err := cacher.Get(context.Background())
+
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenNotExist))
+}
+
+func TestFile_Set_atomic(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+
+	var wg sync.WaitGroup
+
+	// Separate Files don't share a mutex, like separate processes.
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			err := NewFile(path).Set(context.Background(), strings.Repeat("x", 1000*i), time.Now().Add(time.Minute))
+			assert.NoError(err)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := NewFile(path).Get(context.Background())
+			if err != nil {
+				assert.True(errors.Is(err, ErrTokenNotExist), err.Error())
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	entries, err := ioutil.ReadDir(dir)
+	assert.NoError(err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	assert.ElementsMatch([]string{"token", "token.lock"}, names)
+
+	info, err := os.Stat(path)
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestFile_LockRefresh(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "token")
+
+	unlock, err := NewFile(path).LockRefresh(context.Background())
+	assert.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = NewFile(path).LockRefresh(ctx)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+
+	locked := make(chan struct{})
+
+	go func() {
+		unlock, err := NewFile(path).LockRefresh(context.Background())
+		assert.NoError(err)
+		unlock()
+		close(locked)
+	}()
+
+	unlock()
+
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("lock wasn't released")
+	}
+}
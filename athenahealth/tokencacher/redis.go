@@ -2,7 +2,10 @@ package tokencacher
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -10,9 +13,24 @@ import (
 
 const RedisDefaultKey = "athena_token"
 
+// redisUnlockScript deletes a lock only if it is still held by the caller, so that a lock that
+// expired and was taken by another process isn't released.
+var redisUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
 type Redis struct {
 	client *redis.Client
-	key    string
+
+	// baseKey is the key given to NewRedis. key is baseKey with the namespace, if there is one.
+	baseKey string
+	key     string
+
+	// refreshLockTTL is how long the refresh lock is held at most. Zero disables the lock.
+	refreshLockTTL time.Duration
 }
 
 func NewRedis(client *redis.Client, key string) *Redis {
@@ -20,20 +38,50 @@ func NewRedis(client *redis.Client, key string) *Redis {
 		panic("client is nil")
 	}
 
-	r := &Redis{
-		client: client,
-		key:    key,
+	if len(key) == 0 {
+		key = RedisDefaultKey
 	}
 
-	if len(r.key) == 0 {
-		r.key = RedisDefaultKey
+	return &Redis{
+		client:  client,
+		baseKey: key,
+		key:     key,
 	}
+}
+
+// WithNamespace appends the environment and clientID to the key, e.g.
+// "athena_token:preview:<clientID>", so that clients for different environments or credentials
+// can share a Redis server without overwriting each other's tokens. HTTPClient sets the namespace
+// from its own client ID and environment, so it only needs to be called when the cacher is used
+// on its own.
+func (r *Redis) WithNamespace(clientID string, preview bool) *Redis {
+	r.SetNamespace(clientID, preview)
+
+	return r
+}
+
+// SetNamespace replaces the namespace set by WithNamespace. Calling it again with the same values
+// leaves the key unchanged.
+func (r *Redis) SetNamespace(clientID string, preview bool) {
+	env := "prod"
+	if preview {
+		env = "preview"
+	}
+
+	r.key = fmt.Sprintf("%s:%s:%s", r.baseKey, env, clientID)
+}
+
+// WithRefreshLock enables a lock, taken with SET NX, that HTTPClient holds while fetching a new
+// token so that only one replica calls the token provider when the cached token expires. The lock
+// expires after ttl in case its holder dies without releasing it.
+func (r *Redis) WithRefreshLock(ttl time.Duration) *Redis {
+	r.refreshLockTTL = ttl
 
 	return r
 }
 
 func (r *Redis) Get(ctx context.Context) (string, error) {
-	val, err := r.client.Get(context.Background(), r.key).Result()
+	val, err := r.client.Get(ctx, r.key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return "", ErrTokenNotExist
@@ -45,8 +93,81 @@ func (r *Redis) Get(ctx context.Context) (string, error) {
 	return val, nil
 }
 
+// GetWithExpiry returns the token and when it expires, based on its key's TTL.
+func (r *Redis) GetWithExpiry(ctx context.Context) (string, time.Time, error) {
+	pipe := r.client.Pipeline()
+	get := pipe.Get(ctx, r.key)
+	ttl := pipe.PTTL(ctx, r.key)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", time.Time{}, err
+	}
+
+	token, err := get.Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", time.Time{}, ErrTokenNotExist
+		}
+
+		return "", time.Time{}, err
+	}
+
+	var expiresAt time.Time
+	if ttl.Val() > 0 {
+		expiresAt = time.Now().Add(ttl.Val())
+	}
+
+	return token, expiresAt, nil
+}
+
 func (r *Redis) Set(ctx context.Context, token string, expiresAt time.Time) error {
-	_, err := r.client.Set(context.Background(), r.key, token, time.Second*time.Duration(expiresAt.Unix()-time.Now().Unix())).Result()
+	_, err := r.client.Set(ctx, r.key, token, time.Second*time.Duration(expiresAt.Unix()-time.Now().Unix())).Result()
 
 	return err
 }
+
+func (r *Redis) Invalidate(ctx context.Context) error {
+	_, err := r.client.Del(ctx, r.key).Result()
+
+	return err
+}
+
+// LockRefresh takes the refresh lock, waiting until no other replica holds it or ctx is done. It
+// returns immediately if the lock isn't enabled with WithRefreshLock.
+func (r *Redis) LockRefresh(ctx context.Context) (func(), error) {
+	if r.refreshLockTTL <= 0 {
+		return func() {}, nil
+	}
+
+	lockKey := r.key + ":refresh_lock"
+
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	value := hex.EncodeToString(b)
+
+	for {
+		locked, err := r.client.SetNX(ctx, lockKey, value, r.refreshLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if locked {
+			return func() {
+				redisUnlockScript.Run(context.Background(), r.client, []string{lockKey}, value)
+			}, nil
+		}
+
+		t := time.NewTimer(refreshLockPollInterval)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -79,3 +79,195 @@ func TestRedis_Set(t *testing.T) {
 	assert.Equal(expectedToken, token)
 	assert.True(time.Now().Add(time.Second * ttl).After(time.Now()))
 }
+
+func TestRedis_Invalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	s.Set(RedisDefaultKey, "foo")
+
+	cacher := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), "")
+
+	err = cacher.Invalidate(context.Background())
+	assert.NoError(err)
+
+	assert.False(s.Exists(RedisDefaultKey))
+}
+
+func TestRedis_WithNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+
+	preview := NewRedis(client, "").WithNamespace("client-1", true)
+	prod := NewRedis(client, "").WithNamespace("client-1", false)
+	other := NewRedis(client, "").WithNamespace("client-2", false)
+
+	assert.NoError(preview.Set(context.Background(), "preview-token", time.Now().Add(time.Minute)))
+	assert.NoError(prod.Set(context.Background(), "prod-token", time.Now().Add(time.Minute)))
+
+	token, err := preview.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("preview-token", token)
+
+	token, err = prod.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("prod-token", token)
+
+	_, err = other.Get(context.Background())
+	assert.True(errors.Is(err, ErrTokenNotExist))
+
+	assert.True(s.Exists("athena_token:preview:client-1"))
+	assert.True(s.Exists("athena_token:prod:client-1"))
+}
+
+func TestRedis_SetNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRedis(redis.NewClient(&redis.Options{}), "")
+
+	// The namespace replaces the previous one instead of being appended to it.
+	r.WithNamespace("client-1", false).WithNamespace("client-1", false)
+	assert.Equal("athena_token:prod:client-1", r.key)
+
+	r.SetNamespace("client-1", true)
+	assert.Equal("athena_token:preview:client-1", r.key)
+}
+
+func TestRedis_Get_context(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	cacher := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = cacher.Get(ctx)
+	assert.True(errors.Is(err, context.Canceled))
+}
+
+func TestRedis_LockRefresh(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+
+	r1 := NewRedis(client, "").WithRefreshLock(time.Minute)
+	r2 := NewRedis(client, "").WithRefreshLock(time.Minute)
+
+	unlock, err := r1.LockRefresh(context.Background())
+	assert.NoError(err)
+	assert.True(s.Exists(RedisDefaultKey + ":refresh_lock"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = r2.LockRefresh(ctx)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+
+	unlock()
+	assert.False(s.Exists(RedisDefaultKey + ":refresh_lock"))
+
+	unlock, err = r2.LockRefresh(context.Background())
+	assert.NoError(err)
+	unlock()
+}
+
+func TestRedis_LockRefresh_expired(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+
+	r1 := NewRedis(client, "").WithRefreshLock(time.Second)
+	r2 := NewRedis(client, "").WithRefreshLock(time.Minute)
+
+	unlock1, err := r1.LockRefresh(context.Background())
+	assert.NoError(err)
+
+	s.FastForward(2 * time.Second)
+
+	unlock2, err := r2.LockRefresh(context.Background())
+	assert.NoError(err)
+
+	// Releasing an expired lock doesn't release the lock taken after it.
+	unlock1()
+	assert.True(s.Exists(RedisDefaultKey + ":refresh_lock"))
+
+	unlock2()
+	assert.False(s.Exists(RedisDefaultKey + ":refresh_lock"))
+}
+
+func TestRedis_LockRefresh_disabled(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher := NewRedis(redis.NewClient(&redis.Options{}), "")
+
+	unlock, err := cacher.LockRefresh(context.Background())
+	assert.NoError(err)
+	unlock()
+}
+
+func TestRedis_GetWithExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	s.Set(RedisDefaultKey, "foo")
+	s.SetTTL(RedisDefaultKey, time.Minute)
+
+	cacher := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), "")
+
+	token, expiresAt, err := cacher.GetWithExpiry(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+	assert.WithinDuration(time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	s.Del(RedisDefaultKey)
+
+	_, _, err = cacher.GetWithExpiry(context.Background())
+	assert.True(errors.Is(err, ErrTokenNotExist))
+}
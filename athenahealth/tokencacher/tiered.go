@@ -0,0 +1,166 @@
+package tokencacher
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// defaultTieredLocalTTL is how long Tiered keeps a token in its local tier by default.
+const defaultTieredLocalTTL = time.Minute
+
+// Cacher is the interface of every token cacher in this package. It is the same as
+// athenahealth.TokenCacher.
+type Cacher interface {
+	Get(context.Context) (string, error)
+	Set(context.Context, string, time.Time) error
+}
+
+// expiryGetter is implemented by cachers that can return when their token expires.
+type expiryGetter interface {
+	GetWithExpiry(context.Context) (string, time.Time, error)
+}
+
+// invalidator is implemented by cachers that can discard their token.
+type invalidator interface {
+	Invalidate(context.Context) error
+}
+
+// namespacer is implemented by cachers that keep tokens for different environments or
+// credentials apart.
+type namespacer interface {
+	SetNamespace(clientID string, preview bool)
+}
+
+// refreshLocker is implemented by cachers shared by several processes.
+type refreshLocker interface {
+	LockRefresh(context.Context) (func(), error)
+}
+
+// Tiered puts a fast local cacher, e.g. Default, in front of a shared one, e.g. Redis. Reads are
+// served from the local tier and fall through to the shared tier when it has no valid token.
+// Writes and invalidations go to both.
+//
+// The local tier keeps a token for at most its local TTL, so a token replaced in the shared tier
+// by another process is picked up within that time.
+type Tiered struct {
+	local  Cacher
+	shared Cacher
+
+	localTTL time.Duration
+}
+
+func NewTiered(local, shared Cacher) *Tiered {
+	if local == nil || shared == nil {
+		panic("local and shared cachers required")
+	}
+
+	return &Tiered{
+		local:    local,
+		shared:   shared,
+		localTTL: defaultTieredLocalTTL,
+	}
+}
+
+// WithLocalTTL sets how long a token read from the shared tier is kept in the local tier. The
+// token's expiry still applies if it is sooner.
+func (t *Tiered) WithLocalTTL(ttl time.Duration) *Tiered {
+	t.localTTL = ttl
+
+	return t
+}
+
+func (t *Tiered) Get(ctx context.Context) (string, error) {
+	token, err := t.local.Get(ctx)
+	if err == nil || !isMiss(err) {
+		return token, err
+	}
+
+	token, expiresAt, err := getWithExpiry(ctx, t.shared)
+	if err != nil {
+		return "", err
+	}
+
+	localExpiresAt := time.Now().Add(t.localTTL)
+	if !expiresAt.IsZero() && expiresAt.Before(localExpiresAt) {
+		localExpiresAt = expiresAt
+	}
+
+	err = t.local.Set(ctx, token, localExpiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetWithExpiry returns the token from the shared tier along with its expiry.
+func (t *Tiered) GetWithExpiry(ctx context.Context) (string, time.Time, error) {
+	return getWithExpiry(ctx, t.shared)
+}
+
+func (t *Tiered) Set(ctx context.Context, token string, expiresAt time.Time) error {
+	err := t.shared.Set(ctx, token, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	localExpiresAt := time.Now().Add(t.localTTL)
+	if expiresAt.Before(localExpiresAt) {
+		localExpiresAt = expiresAt
+	}
+
+	return t.local.Set(ctx, token, localExpiresAt)
+}
+
+// Invalidate discards the token from both tiers.
+func (t *Tiered) Invalidate(ctx context.Context) error {
+	for _, c := range []Cacher{t.local, t.shared} {
+		if i, ok := c.(invalidator); ok {
+			err := i.Invalidate(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetNamespace sets the namespace of both tiers, if they have one.
+func (t *Tiered) SetNamespace(clientID string, preview bool) {
+	for _, c := range []Cacher{t.local, t.shared} {
+		if n, ok := c.(namespacer); ok {
+			n.SetNamespace(clientID, preview)
+		}
+	}
+}
+
+// LockRefresh takes the shared tier's refresh lock, if it has one.
+func (t *Tiered) LockRefresh(ctx context.Context) (func(), error) {
+	if l, ok := t.shared.(refreshLocker); ok {
+		return l.LockRefresh(ctx)
+	}
+
+	return func() {}, nil
+}
+
+// getWithExpiry returns c's token and its expiry. The expiry is zero if c can't tell.
+func getWithExpiry(ctx context.Context, c Cacher) (string, time.Time, error) {
+	if g, ok := c.(expiryGetter); ok {
+		return g.GetWithExpiry(ctx)
+	}
+
+	token, err := c.Get(ctx)
+
+	return token, time.Time{}, err
+}
+
+// isMiss reports whether err means the cacher has no usable token. A token that fails
+// authentication, e.g. in an encrypted File, is a miss too, so that Get falls through to the shared
+// tier and Set replaces it.
+func isMiss(err error) bool {
+	return errors.Is(err, ErrTokenNotExist) ||
+		errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrTokenTampered)
+}
@@ -0,0 +1,180 @@
+package tokencacher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTiered() (*Tiered, *Default, *miniredis.Miniredis) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+
+	local := NewDefault()
+	shared := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), "")
+
+	return NewTiered(local, shared), local, s
+}
+
+func TestTiered_Get(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher, local, s := testTiered()
+	defer s.Close()
+
+	s.Set(RedisDefaultKey, "foo")
+	s.SetTTL(RedisDefaultKey, 10*time.Second)
+
+	token, err := cacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	// The token was copied to the local tier with the shared tier's expiry.
+	token, expiresAt, err := local.GetWithExpiry(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+	assert.WithinDuration(time.Now().Add(10*time.Second), expiresAt, time.Second)
+
+	// Later reads are served from the local tier.
+	s.Del(RedisDefaultKey)
+
+	token, err = cacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+}
+
+func TestTiered_Get_localTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher, local, s := testTiered()
+	defer s.Close()
+
+	cacher.WithLocalTTL(time.Millisecond)
+
+	s.Set(RedisDefaultKey, "foo")
+	s.SetTTL(RedisDefaultKey, time.Hour)
+
+	token, err := cacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = local.Get(context.Background())
+	assert.True(errors.Is(err, ErrTokenExpired))
+
+	// Another process replaced the token in the shared tier.
+	s.Set(RedisDefaultKey, "bar")
+
+	token, err = cacher.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("bar", token)
+}
+
+func TestTiered_Get_ErrTokenNotExist(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher, _, s := testTiered()
+	defer s.Close()
+
+	token, err := cacher.Get(context.Background())
+	assert.Empty(token)
+	assert.True(errors.Is(err, ErrTokenNotExist))
+}
+
+func TestTiered_Get_localTampered(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	// The local tier is an encrypted File holding a plaintext token.
+	path := filepath.Join(t.TempDir(), "token")
+	assert.NoError(NewFile(path).Set(context.Background(), "plaintext", time.Now().Add(time.Hour)))
+
+	local := NewFile(path).WithEncryption(bytes.Repeat([]byte{1}, 32))
+	shared := NewRedis(redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	}), "")
+
+	s.Set(RedisDefaultKey, "foo")
+	s.SetTTL(RedisDefaultKey, time.Hour)
+
+	token, err := NewTiered(local, shared).Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	// The local tier was replaced with the shared tier's token.
+	token, err = local.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+}
+
+func TestTiered_Set(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher, local, s := testTiered()
+	defer s.Close()
+
+	expiresAt := time.Now().Add(30 * time.Second)
+
+	err := cacher.Set(context.Background(), "foo", expiresAt)
+	assert.NoError(err)
+
+	token, err := s.Get(RedisDefaultKey)
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	token, localExpiresAt, err := local.GetWithExpiry(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+	assert.True(expiresAt.Equal(localExpiresAt))
+}
+
+func TestTiered_Invalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	cacher, local, s := testTiered()
+	defer s.Close()
+
+	err := cacher.Set(context.Background(), "foo", time.Now().Add(time.Minute))
+	assert.NoError(err)
+
+	err = cacher.Invalidate(context.Background())
+	assert.NoError(err)
+
+	assert.False(s.Exists(RedisDefaultKey))
+
+	_, err = local.Get(context.Background())
+	assert.True(errors.Is(err, ErrTokenNotExist))
+
+	_, err = cacher.Get(context.Background())
+	assert.True(errors.Is(err, ErrTokenNotExist))
+}
+
+func TestTiered_SetNamespace(t *testing.T) {
+	assert := assert.New(t)
+
+	tiered, _, s := testTiered()
+	defer s.Close()
+
+	tiered.SetNamespace("client-1", true)
+
+	assert.NoError(tiered.Set(context.Background(), "foo", time.Now().Add(time.Hour)))
+	assert.True(s.Exists("athena_token:preview:client-1"))
+	assert.False(s.Exists(RedisDefaultKey))
+}
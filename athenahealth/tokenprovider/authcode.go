@@ -0,0 +1,236 @@
+package tokenprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// PreviewAuthorizeURL is the URL users are sent to to authorize an app in the preview
+	// environment.
+	PreviewAuthorizeURL = "https://api.preview.platform.athenahealth.com/oauth2/v1/authorize"
+
+	// ProdAuthorizeURL is the URL users are sent to to authorize an app in the production
+	// environment.
+	ProdAuthorizeURL = "https://api.platform.athenahealth.com/oauth2/v1/authorize"
+)
+
+// ErrAuthorizationRequired is returned by AuthorizationCode.Provide when there is no refresh token,
+// i.e. the user has to authorize the app (again) before a token can be provided.
+var ErrAuthorizationRequired = errors.New("user authorization required")
+
+// AuthorizationCode gets user-context tokens with the authorization code grant and PKCE, as used by
+// SMART on FHIR apps. The user is sent to AuthCodeURL, the code athenahealth redirects back with is
+// passed to Exchange, and Provide then refreshes the token with the refresh token kept in the
+// RefreshTokenStore. An AuthorizationCode provides tokens for a single user.
+type AuthorizationCode struct {
+	httpClient *http.Client
+
+	clientID    string
+	secret      string
+	redirectURI string
+
+	authorizeURL string
+	authURL      string
+	scopes       []string
+	audience     string
+
+	store RefreshTokenStore
+
+	lock sync.Mutex
+
+	// token is the access token returned by Exchange. The first Provide call returns it instead of
+	// refreshing straight away.
+	token     string
+	expiresAt time.Time
+}
+
+// NewAuthorizationCode returns an AuthorizationCode for the app with clientID. secret may be empty
+// for public apps, which authenticate with PKCE alone. redirectURI must match one registered for the
+// app. Refresh tokens are kept in memory until WithRefreshTokenStore is called.
+func NewAuthorizationCode(httpClient *http.Client, clientID, secret, redirectURI string, preview bool) *AuthorizationCode {
+	a := &AuthorizationCode{
+		httpClient: httpClient,
+
+		clientID:    clientID,
+		secret:      secret,
+		redirectURI: redirectURI,
+
+		scopes: []string{"openid", "fhirUser", "offline_access"},
+
+		store: NewMemoryRefreshTokenStore(),
+	}
+
+	if preview {
+		a.authorizeURL = PreviewAuthorizeURL
+		a.authURL = PreviewAuthURL
+	} else {
+		a.authorizeURL = ProdAuthorizeURL
+		a.authURL = ProdAuthURL
+	}
+
+	return a
+}
+
+// WithScopes sets the scopes requested in AuthCodeURL. offline_access is needed to get a refresh
+// token.
+func (a *AuthorizationCode) WithScopes(scopes ...string) *AuthorizationCode {
+	a.scopes = scopes
+
+	return a
+}
+
+// WithAudience sets the aud parameter of AuthCodeURL, which SMART on FHIR apps set to the FHIR
+// server's base URL.
+func (a *AuthorizationCode) WithAudience(audience string) *AuthorizationCode {
+	a.audience = audience
+
+	return a
+}
+
+// WithRefreshTokenStore sets where the user's refresh token is kept.
+func (a *AuthorizationCode) WithRefreshTokenStore(store RefreshTokenStore) *AuthorizationCode {
+	a.store = store
+
+	return a
+}
+
+// AuthCodeURL returns the URL to send the user to and the PKCE code verifier to pass to Exchange
+// with the code athenahealth redirects back with. state is returned unchanged in the redirect and
+// should be checked by the caller to prevent CSRF. The verifier must be kept secret, e.g. in the
+// user's session.
+func (a *AuthorizationCode) AuthCodeURL(state string) (string, string, error) {
+	verifier, err := codeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	vals := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.clientID},
+		"redirect_uri":          {a.redirectURI},
+		"scope":                 {strings.Join(a.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	if len(a.audience) > 0 {
+		vals.Set("aud", a.audience)
+	}
+
+	return a.authorizeURL + "?" + vals.Encode(), verifier, nil
+}
+
+// Exchange exchanges the authorization code for a token. The refresh token is saved in the
+// RefreshTokenStore and the access token is returned by the next call to Provide.
+func (a *AuthorizationCode) Exchange(ctx context.Context, code, verifier string) error {
+	vals := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.redirectURI},
+		"code_verifier": {verifier},
+	}
+
+	authRes, expiresAt, err := a.postToken(ctx, vals)
+	if err != nil {
+		return err
+	}
+
+	if len(authRes.RefreshToken) > 0 {
+		err = a.store.Set(ctx, authRes.RefreshToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.token = authRes.AccessToken
+	a.expiresAt = expiresAt
+
+	return nil
+}
+
+// Provide returns the token from Exchange if it hasn't been provided yet and otherwise gets a new
+// one with the refresh token. It returns ErrAuthorizationRequired if there is no refresh token.
+func (a *AuthorizationCode) Provide(ctx context.Context) (string, time.Time, error) {
+	a.lock.Lock()
+	token, expiresAt := a.token, a.expiresAt
+	a.token, a.expiresAt = "", time.Time{}
+	a.lock.Unlock()
+
+	if len(token) > 0 && time.Now().Before(expiresAt) {
+		return token, expiresAt, nil
+	}
+
+	refreshToken, err := a.store.Get(ctx)
+	if errors.Is(err, ErrRefreshTokenNotExist) {
+		return "", time.Now(), ErrAuthorizationRequired
+	}
+	if err != nil {
+		return "", time.Now(), err
+	}
+
+	vals := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+
+	authRes, expiresAt, err := a.postToken(ctx, vals)
+	if err != nil {
+		return "", time.Now(), err
+	}
+
+	// athenahealth may rotate the refresh token.
+	if len(authRes.RefreshToken) > 0 && authRes.RefreshToken != refreshToken {
+		err = a.store.Set(ctx, authRes.RefreshToken)
+		if err != nil {
+			return "", time.Now(), err
+		}
+	}
+
+	return authRes.AccessToken, expiresAt, nil
+}
+
+// postToken authenticates with the client secret if there is one and otherwise sends the client ID
+// in the form, as public clients do.
+func (a *AuthorizationCode) postToken(ctx context.Context, vals url.Values) (*authResponse, time.Time, error) {
+	if len(a.secret) == 0 {
+		vals.Set("client_id", a.clientID)
+
+		return postToken(ctx, a.httpClient, a.authURL, vals, nil)
+	}
+
+	return postToken(ctx, a.httpClient, a.authURL, vals, func(req *http.Request) {
+		req.SetBasicAuth(a.clientID, a.secret)
+	})
+}
+
+// codeVerifier returns a random PKCE code verifier.
+func codeVerifier() (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge for verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
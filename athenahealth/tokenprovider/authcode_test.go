@@ -0,0 +1,230 @@
+package tokenprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAuthServer is a minimal OAuth 2.0 authorization server. Its authorize endpoint approves every
+// request and redirects back with a code, and its token endpoint checks the PKCE verifier and
+// rotates refresh tokens.
+type fakeAuthServer struct {
+	*httptest.Server
+
+	t *testing.T
+
+	lock       sync.Mutex
+	issued     int
+	challenges map[string]string
+	refresh    map[string]bool
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	f := &fakeAuthServer{
+		t:          t,
+		challenges: make(map[string]string),
+		refresh:    make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", f.authorize)
+	mux.HandleFunc("/token", f.token)
+
+	f.Server = httptest.NewServer(mux)
+
+	return f
+}
+
+func (f *fakeAuthServer) authorize(w http.ResponseWriter, r *http.Request) {
+	assert := assert.New(f.t)
+
+	q := r.URL.Query()
+
+	assert.Equal("code", q.Get("response_type"))
+	assert.Equal("client-id", q.Get("client_id"))
+	assert.Equal("S256", q.Get("code_challenge_method"))
+
+	f.lock.Lock()
+	code := fmt.Sprintf("code-%d", len(f.challenges))
+	f.challenges[code] = q.Get("code_challenge")
+	f.lock.Unlock()
+
+	redirect := q.Get("redirect_uri") + "?" + url.Values{
+		"code":  {code},
+		"state": {q.Get("state")},
+	}.Encode()
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (f *fakeAuthServer) token(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		challenge, ok := f.challenges[r.FormValue("code")]
+		delete(f.challenges, r.FormValue("code"))
+
+		if !ok || codeChallenge(r.FormValue("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	case "refresh_token":
+		if !f.refresh[r.FormValue("refresh_token")] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		delete(f.refresh, r.FormValue("refresh_token"))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.issued++
+	refreshToken := fmt.Sprintf("refresh-%d", f.issued)
+	f.refresh[refreshToken] = true
+
+	b, _ := json.Marshal(&authResponse{
+		AccessToken:  fmt.Sprintf("access-%d", f.issued),
+		RefreshToken: refreshToken,
+		ExpiresIn:    "3600",
+	})
+	w.Write(b)
+}
+
+func TestAuthorizationCode(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := newFakeAuthServer(t)
+	defer ts.Close()
+
+	store := NewMemoryRefreshTokenStore()
+
+	p := NewAuthorizationCode(ts.Client(), "client-id", "", "https://app.example.com/callback", true).
+		WithScopes("openid", "offline_access", "patient/Patient.read").
+		WithAudience("https://fhir.example.com").
+		WithRefreshTokenStore(store)
+	p.authorizeURL = ts.URL + "/authorize"
+	p.authURL = ts.URL + "/token"
+
+	_, _, err := p.Provide(context.Background())
+	assert.Equal(ErrAuthorizationRequired, err)
+
+	authCodeURL, verifier, err := p.AuthCodeURL("state-1")
+	assert.NoError(err)
+
+	u, err := url.Parse(authCodeURL)
+	assert.NoError(err)
+	assert.Equal("openid offline_access patient/Patient.read", u.Query().Get("scope"))
+	assert.Equal("https://fhir.example.com", u.Query().Get("aud"))
+	assert.NotContains(authCodeURL, verifier)
+
+	// Play the user's browser.
+	httpClient := ts.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	res, err := httpClient.Get(authCodeURL)
+	assert.NoError(err)
+	res.Body.Close()
+
+	callback, err := res.Location()
+	assert.NoError(err)
+	assert.Equal("app.example.com", callback.Host)
+	assert.Equal("state-1", callback.Query().Get("state"))
+
+	// The code can't be exchanged without the verifier.
+	_, badVerifier, _ := p.AuthCodeURL("state-2")
+	assert.Error(p.Exchange(context.Background(), callback.Query().Get("code"), badVerifier))
+
+	res, err = httpClient.Get(authCodeURL)
+	assert.NoError(err)
+	res.Body.Close()
+
+	callback, _ = res.Location()
+
+	err = p.Exchange(context.Background(), callback.Query().Get("code"), verifier)
+	assert.NoError(err)
+
+	refreshToken, err := store.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("refresh-1", refreshToken)
+
+	token, _, err := p.Provide(context.Background())
+	assert.NoError(err)
+	assert.Equal("access-1", token)
+
+	token, _, err = p.Provide(context.Background())
+	assert.NoError(err)
+	assert.Equal("access-2", token)
+
+	refreshToken, err = store.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal("refresh-2", refreshToken)
+
+	// A refresh token from before the rotation is rejected.
+	store.Set(context.Background(), "refresh-1")
+
+	_, _, err = p.Provide(context.Background())
+	assert.Error(err)
+}
+
+func TestAuthorizationCode_secret(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		assert.True(ok)
+		assert.Equal("client-id", clientID)
+		assert.Equal("secret", secret)
+		assert.Empty(r.FormValue("client_id"))
+
+		w.Write([]byte(`{"access_token": "foo", "expires_in": "3600"}`))
+	}))
+	defer ts.Close()
+
+	store := NewMemoryRefreshTokenStore()
+	store.Set(context.Background(), "refresh")
+
+	p := NewAuthorizationCode(ts.Client(), "client-id", "secret", "https://app.example.com/callback", false).
+		WithRefreshTokenStore(store)
+	p.authURL = ts.URL
+
+	token, _, err := p.Provide(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	// The refresh token isn't replaced if the response doesn't include a new one.
+	refreshToken, _ := store.Get(context.Background())
+	assert.Equal("refresh", refreshToken)
+}
+
+func TestNewAuthorizationCode(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewAuthorizationCode(&http.Client{}, "client-id", "", "https://app.example.com/callback", true)
+	assert.Equal(PreviewAuthorizeURL, p.authorizeURL)
+	assert.Equal(PreviewAuthURL, p.authURL)
+
+	p = NewAuthorizationCode(&http.Client{}, "client-id", "", "https://app.example.com/callback", false)
+	assert.Equal(ProdAuthorizeURL, p.authorizeURL)
+	assert.Equal(ProdAuthURL, p.authURL)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	assert := assert.New(t)
+
+	// From RFC 7636 appendix B.
+	assert.Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
@@ -4,10 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -17,6 +17,10 @@ const (
 
 	// ProdAuthURL is the URL used to authenticate in the production environment.
 	ProdAuthURL = "https://api.platform.athenahealth.com/oauth2/v1/token"
+
+	// DefaultScope is the scope requested by the client credentials providers unless they're given
+	// other scopes.
+	DefaultScope = "athena/service/Athenanet.MDP.*"
 )
 
 type Default struct {
@@ -26,6 +30,7 @@ type Default struct {
 	secret   string
 
 	authURL string
+	scopes  []string
 }
 
 func NewDefault(httpClient *http.Client, clientID, secret string, preview bool) *Default {
@@ -34,6 +39,8 @@ func NewDefault(httpClient *http.Client, clientID, secret string, preview bool)
 
 		clientID: clientID,
 		secret:   secret,
+
+		scopes: []string{DefaultScope},
 	}
 
 	if preview {
@@ -45,52 +52,87 @@ func NewDefault(httpClient *http.Client, clientID, secret string, preview bool)
 	return d
 }
 
+// WithScopes sets the scopes requested with each token, replacing DefaultScope.
+func (d *Default) WithScopes(scopes ...string) *Default {
+	d.scopes = scopes
+
+	return d
+}
+
+// Scopes returns the scopes requested with each token.
+func (d *Default) Scopes() []string {
+	return d.scopes
+}
+
 type authResponse struct {
-	AccessToken string      `json:"access_token"`
-	ExpiresIn   json.Number `json:"expires_in"`
+	AccessToken  string      `json:"access_token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    json.Number `json:"expires_in"`
 }
 
 func (d *Default) Provide(ctx context.Context) (string, time.Time, error) {
 	vals := url.Values{
 		"grant_type": {"client_credentials"},
-		"scope":      {"athena/service/Athenanet.MDP.*"},
+		"scope":      {strings.Join(d.scopes, " ")},
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", d.authURL, bytes.NewBufferString(vals.Encode()))
+	return requestToken(ctx, d.httpClient, d.authURL, vals, func(req *http.Request) {
+		req.SetBasicAuth(d.clientID, d.secret)
+	})
+}
+
+// requestToken posts vals to the token endpoint at authURL and returns the access token from its
+// response. authenticate adds the client's credentials to the request, if it needs to.
+func requestToken(ctx context.Context, httpClient *http.Client, authURL string, vals url.Values, authenticate func(*http.Request)) (string, time.Time, error) {
+	authRes, expiresAt, err := postToken(ctx, httpClient, authURL, vals, authenticate)
 	if err != nil {
 		return "", time.Now(), err
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.SetBasicAuth(d.clientID, d.secret)
+	return authRes.AccessToken, expiresAt, nil
+}
 
-	res, err := d.httpClient.Do(req)
+// postToken posts vals to the token endpoint at authURL and returns its response and when the
+// access token expires.
+func postToken(ctx context.Context, httpClient *http.Client, authURL string, vals url.Values, authenticate func(*http.Request)) (*authResponse, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", authURL, bytes.NewBufferString(vals.Encode()))
 	if err != nil {
-		return "", time.Now(), err
+		return nil, time.Now(), err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return "", time.Now(), fmt.Errorf("%s", res.Status)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	if authenticate != nil {
+		authenticate(req)
 	}
 
-	b, err := ioutil.ReadAll(res.Body)
+	res, err := httpClient.Do(req)
 	if err != nil {
-		return "", time.Now(), err
+		return nil, time.Now(), err
 	}
+
+	b, err := ioutil.ReadAll(res.Body)
 	res.Body.Close()
+	if err != nil {
+		return nil, time.Now(), err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Now(), newTokenError(res, b)
+	}
 
 	authRes := &authResponse{}
 	err = json.Unmarshal(b, authRes)
 	if err != nil {
-		return "", time.Now(), err
+		return nil, time.Now(), err
 	}
 
 	expiresIn, err := authRes.ExpiresIn.Int64()
 	if err != nil {
-		return "", time.Now(), err
+		return nil, time.Now(), err
 	}
 
 	expiresAt := time.Now().Add(time.Second * time.Duration(expiresIn))
 
-	return authRes.AccessToken, expiresAt, nil
+	return authRes, expiresAt, nil
 }
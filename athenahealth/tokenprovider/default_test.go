@@ -56,3 +56,23 @@ func TestDefault_Provide(t *testing.T) {
 	assert.True(expiresAt.After(time.Now()))
 	assert.NoError(err)
 }
+
+func TestDefault_WithScopes(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("athena/service/Athenanet.MDP.* system/Patient.read", r.FormValue("scope"))
+
+		w.Write([]byte(`{"access_token": "foo", "expires_in": "60"}`))
+	}))
+	defer ts.Close()
+
+	p := NewDefault(ts.Client(), "", "", false)
+	assert.Equal([]string{DefaultScope}, p.Scopes())
+
+	p.WithScopes(DefaultScope, "system/Patient.read")
+	p.authURL = ts.URL
+
+	_, _, err := p.Provide(context.Background())
+	assert.NoError(err)
+}
@@ -0,0 +1,59 @@
+package tokenprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TokenError is returned when the token endpoint responds with an error. ErrorCode and Description
+// are the error and error_description of an OAuth 2.0 error response, if the body was one.
+type TokenError struct {
+	StatusCode int
+	Status     string
+
+	ErrorCode   string
+	Description string
+
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (t *TokenError) Error() string {
+	if len(t.ErrorCode) == 0 {
+		return t.Status
+	}
+
+	if len(t.Description) == 0 {
+		return fmt.Sprintf("%s: %s", t.Status, t.ErrorCode)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", t.Status, t.ErrorCode, t.Description)
+}
+
+// InvalidClient reports whether the token endpoint rejected the client's credentials.
+func (t *TokenError) InvalidClient() bool {
+	return t.StatusCode == http.StatusUnauthorized || t.ErrorCode == "invalid_client"
+}
+
+type oauthErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// newTokenError returns a TokenError for an error response from the token endpoint.
+func newTokenError(res *http.Response, body []byte) *TokenError {
+	t := &TokenError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       body,
+	}
+
+	errRes := &oauthErrorResponse{}
+	if json.Unmarshal(body, errRes) == nil {
+		t.ErrorCode = errRes.Error
+		t.Description = errRes.Description
+	}
+
+	return t
+}
@@ -0,0 +1,32 @@
+package tokenprovider
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTokenError(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Status:     "400 Bad Request",
+	}
+
+	err := newTokenError(res, []byte(`{"error": "invalid_scope", "error_description": "Unknown scope"}`))
+	assert.Equal("invalid_scope", err.ErrorCode)
+	assert.Equal("Unknown scope", err.Description)
+	assert.Equal("400 Bad Request: invalid_scope: Unknown scope", err.Error())
+	assert.False(err.InvalidClient())
+
+	err = newTokenError(res, []byte(`{"error": "invalid_client"}`))
+	assert.Equal("400 Bad Request: invalid_client", err.Error())
+	assert.True(err.InvalidClient())
+
+	err = newTokenError(res, []byte("<html>Bad Request</html>"))
+	assert.Empty(err.ErrorCode)
+	assert.Equal("400 Bad Request", err.Error())
+	assert.Equal([]byte("<html>Bad Request</html>"), err.Body)
+}
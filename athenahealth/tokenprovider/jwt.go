@@ -0,0 +1,188 @@
+package tokenprovider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientAssertionType is the client_assertion_type of a JWT client assertion.
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// assertionLifetime is how long a client assertion is valid for. It only needs to outlive the token
+// request.
+const assertionLifetime = 5 * time.Minute
+
+// JWTAssertion gets tokens with the client_credentials grant, authenticating with a client
+// assertion JWT signed by the client's private key (private_key_jwt) instead of a shared secret.
+type JWTAssertion struct {
+	httpClient *http.Client
+
+	clientID   string
+	privateKey crypto.Signer
+	keyID      string
+	alg        string
+
+	authURL string
+	scopes  []string
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// NewJWTAssertion returns a JWTAssertion that signs client assertions with privateKey, which must
+// be an *rsa.PrivateKey (RS384) or a P-384 *ecdsa.PrivateKey (ES384). keyID is sent in the JWT's
+// kid header so that athenahealth can pick the matching public key.
+func NewJWTAssertion(httpClient *http.Client, clientID string, privateKey crypto.Signer, keyID string, preview bool) *JWTAssertion {
+	j := &JWTAssertion{
+		httpClient: httpClient,
+
+		clientID:   clientID,
+		privateKey: privateKey,
+		keyID:      keyID,
+
+		scopes: []string{DefaultScope},
+
+		now: time.Now,
+	}
+
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		j.alg = "RS384"
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P384() {
+			panic("ES384 requires a P-384 key")
+		}
+
+		j.alg = "ES384"
+	default:
+		panic(fmt.Sprintf("unsupported private key type %T", privateKey))
+	}
+
+	if preview {
+		j.authURL = PreviewAuthURL
+	} else {
+		j.authURL = ProdAuthURL
+	}
+
+	return j
+}
+
+// WithScopes sets the scopes requested with each token, replacing DefaultScope.
+func (j *JWTAssertion) WithScopes(scopes ...string) *JWTAssertion {
+	j.scopes = scopes
+
+	return j
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+type jwtClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (j *JWTAssertion) Provide(ctx context.Context) (string, time.Time, error) {
+	assertion, err := j.assertion()
+	if err != nil {
+		return "", time.Now(), err
+	}
+
+	vals := url.Values{
+		"grant_type":            {"client_credentials"},
+		"scope":                 {strings.Join(j.scopes, " ")},
+		"client_id":             {j.clientID},
+		"client_assertion_type": {ClientAssertionType},
+		"client_assertion":      {assertion},
+	}
+
+	return requestToken(ctx, j.httpClient, j.authURL, vals, nil)
+}
+
+// assertion returns a signed client assertion with a unique ID that expires shortly.
+func (j *JWTAssertion) assertion() (string, error) {
+	jti := make([]byte, 16)
+	_, err := rand.Read(jti)
+	if err != nil {
+		return "", err
+	}
+
+	now := j.now()
+
+	header, err := json.Marshal(&jwtHeader{
+		Alg: j.alg,
+		Typ: "JWT",
+		Kid: j.keyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(&jwtClaims{
+		Issuer:    j.clientID,
+		Subject:   j.clientID,
+		Audience:  j.authURL,
+		ID:        hex.EncodeToString(jti),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(assertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	signature, err := j.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (j *JWTAssertion) sign(b []byte) ([]byte, error) {
+	digest := sha512.Sum384(b)
+
+	switch k := j.privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+
+		// JWS uses the fixed size concatenation of r and s instead of ASN.1.
+		return append(padBigInt(r, 48), padBigInt(s, 48)...), nil
+	default:
+		return j.privateKey.Sign(rand.Reader, digest[:], crypto.SHA384)
+	}
+}
+
+func padBigInt(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
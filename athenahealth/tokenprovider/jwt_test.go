@@ -0,0 +1,167 @@
+package tokenprovider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testVerifyingTokenServer is a token endpoint that only returns a token for client assertions
+// signed by publicKey. It records the assertions' claims.
+func testVerifyingTokenServer(t *testing.T, publicKey crypto.PublicKey, claims *[]*jwtClaims) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert := assert.New(t)
+
+		assert.Equal("client_credentials", r.FormValue("grant_type"))
+		assert.Equal(ClientAssertionType, r.FormValue("client_assertion_type"))
+
+		_, _, ok := r.BasicAuth()
+		assert.False(ok)
+
+		parts := strings.Split(r.FormValue("client_assertion"), ".")
+		if !assert.Len(parts, 3) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		assert.NoError(err)
+
+		digest := sha512.Sum384([]byte(parts[0] + "." + parts[1]))
+
+		var valid bool
+
+		switch k := publicKey.(type) {
+		case *rsa.PublicKey:
+			valid = rsa.VerifyPKCS1v15(k, crypto.SHA384, digest[:], signature) == nil
+		case *ecdsa.PublicKey:
+			valid = len(signature) == 96 && ecdsa.Verify(k, digest[:],
+				new(big.Int).SetBytes(signature[:48]),
+				new(big.Int).SetBytes(signature[48:]))
+		}
+
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		header := &jwtHeader{}
+		b, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		assert.NoError(json.Unmarshal(b, header))
+		assert.Equal("key-1", header.Kid)
+
+		c := &jwtClaims{}
+		b, _ = base64.RawURLEncoding.DecodeString(parts[1])
+		assert.NoError(json.Unmarshal(b, c))
+		*claims = append(*claims, c)
+
+		w.Write([]byte(`{"access_token": "foo", "expires_in": "3600"}`))
+	}))
+}
+
+func TestJWTAssertion_Provide_RS384(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims []*jwtClaims
+
+	ts := testVerifyingTokenServer(t, &key.PublicKey, &claims)
+	defer ts.Close()
+
+	p := NewJWTAssertion(ts.Client(), "client-id", key, "key-1", false)
+	p.authURL = ts.URL
+
+	assert.Equal("RS384", p.alg)
+
+	for i := 0; i < 2; i++ {
+		token, expiresAt, err := p.Provide(context.Background())
+		assert.NoError(err)
+		assert.Equal("foo", token)
+		assert.True(expiresAt.After(time.Now()))
+	}
+
+	assert.Len(claims, 2)
+	assert.Equal("client-id", claims[0].Issuer)
+	assert.Equal("client-id", claims[0].Subject)
+	assert.Equal(ts.URL, claims[0].Audience)
+	assert.Equal(int64(300), claims[0].ExpiresAt-claims[0].IssuedAt)
+	assert.NotEqual(claims[0].ID, claims[1].ID)
+}
+
+func TestJWTAssertion_Provide_ES384(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims []*jwtClaims
+
+	ts := testVerifyingTokenServer(t, &key.PublicKey, &claims)
+	defer ts.Close()
+
+	p := NewJWTAssertion(ts.Client(), "client-id", key, "key-1", true)
+	p.authURL = ts.URL
+
+	assert.Equal("ES384", p.alg)
+
+	token, _, err := p.Provide(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+	assert.Len(claims, 1)
+}
+
+func TestJWTAssertion_Provide_wrongKey(t *testing.T) {
+	assert := assert.New(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	var claims []*jwtClaims
+
+	ts := testVerifyingTokenServer(t, &otherKey.PublicKey, &claims)
+	defer ts.Close()
+
+	p := NewJWTAssertion(ts.Client(), "client-id", key, "key-1", false)
+	p.authURL = ts.URL
+
+	token, _, err := p.Provide(context.Background())
+	assert.Empty(token)
+	assert.Error(err)
+}
+
+func TestNewJWTAssertion(t *testing.T) {
+	assert := assert.New(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	p := NewJWTAssertion(&http.Client{}, "client-id", key, "key-1", true)
+	assert.Equal(PreviewAuthURL, p.authURL)
+
+	p = NewJWTAssertion(&http.Client{}, "client-id", key, "key-1", false)
+	assert.Equal(ProdAuthURL, p.authURL)
+
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Panics(func() {
+		NewJWTAssertion(&http.Client{}, "client-id", p256, "key-1", false)
+	})
+}
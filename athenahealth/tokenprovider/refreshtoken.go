@@ -0,0 +1,49 @@
+package tokenprovider
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrRefreshTokenNotExist is returned by RefreshTokenStores that have no refresh token.
+var ErrRefreshTokenNotExist = errors.New("refresh token does not exist")
+
+// RefreshTokenStore keeps the refresh token of an AuthorizationCode provider, e.g. in the user's
+// database record, so that it survives restarts. Get returns ErrRefreshTokenNotExist if there is
+// no refresh token.
+type RefreshTokenStore interface {
+	Get(context.Context) (string, error)
+	Set(context.Context, string) error
+}
+
+// MemoryRefreshTokenStore keeps the refresh token in memory.
+type MemoryRefreshTokenStore struct {
+	token string
+
+	lock sync.Mutex
+}
+
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{}
+}
+
+func (m *MemoryRefreshTokenStore) Get(ctx context.Context) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.token) == 0 {
+		return "", ErrRefreshTokenNotExist
+	}
+
+	return m.token, nil
+}
+
+func (m *MemoryRefreshTokenStore) Set(ctx context.Context, token string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.token = token
+
+	return nil
+}
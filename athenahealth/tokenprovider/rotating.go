@@ -0,0 +1,126 @@
+package tokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoCredentials is returned by Rotating when it has no credentials to try.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Credential is a client ID and secret.
+type Credential struct {
+	ClientID string
+	Secret   string
+}
+
+// CredentialsFunc returns the credentials to try, in order. It is called for every token, so it
+// can pick up a rotated secret without a restart.
+type CredentialsFunc func(context.Context) ([]Credential, error)
+
+// Rotating gets tokens like Default, but tries several credentials in order and falls back to the
+// next one when the token endpoint rejects a credential. During a secret rotation it is given both
+// the new and the old secret, so that it keeps working whichever one athenahealth accepts.
+type Rotating struct {
+	httpClient *http.Client
+
+	credentials  CredentialsFunc
+	onCredential func(index int, clientID string)
+
+	authURL string
+	scopes  []string
+}
+
+// NewRotating returns a Rotating that tries credentials in order. credentials is copied, so
+// changing it afterwards doesn't affect the Rotating.
+func NewRotating(httpClient *http.Client, credentials []Credential, preview bool) *Rotating {
+	credentials = append([]Credential(nil), credentials...)
+
+	return NewRotatingFunc(httpClient, func(context.Context) ([]Credential, error) {
+		return credentials, nil
+	}, preview)
+}
+
+// NewRotatingFunc returns a Rotating that tries the credentials returned by credentials in order.
+func NewRotatingFunc(httpClient *http.Client, credentials CredentialsFunc, preview bool) *Rotating {
+	r := &Rotating{
+		httpClient: httpClient,
+
+		credentials: credentials,
+
+		scopes: []string{DefaultScope},
+	}
+
+	if preview {
+		r.authURL = PreviewAuthURL
+	} else {
+		r.authURL = ProdAuthURL
+	}
+
+	return r
+}
+
+// WithScopes sets the scopes requested with each token, replacing DefaultScope.
+func (r *Rotating) WithScopes(scopes ...string) *Rotating {
+	r.scopes = scopes
+
+	return r
+}
+
+// WithOnCredential sets a function that is called with the index and client ID of the credential
+// each token was fetched with, e.g. to log when the old secret is no longer used.
+func (r *Rotating) WithOnCredential(fn func(index int, clientID string)) *Rotating {
+	r.onCredential = fn
+
+	return r
+}
+
+// Provide tries each credential in order until one is accepted. It falls back to the next
+// credential only if the token endpoint rejects the current one; other errors are returned
+// straight away. If every credential is rejected, the last TokenError is returned.
+func (r *Rotating) Provide(ctx context.Context) (string, time.Time, error) {
+	credentials, err := r.credentials(ctx)
+	if err != nil {
+		return "", time.Now(), err
+	}
+
+	if len(credentials) == 0 {
+		return "", time.Now(), ErrNoCredentials
+	}
+
+	vals := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {strings.Join(r.scopes, " ")},
+	}
+
+	var lastErr error
+
+	for i, c := range credentials {
+		c := c
+
+		token, expiresAt, err := requestToken(ctx, r.httpClient, r.authURL, vals, func(req *http.Request) {
+			req.SetBasicAuth(c.ClientID, c.Secret)
+		})
+		if err != nil {
+			var tokenErr *TokenError
+			if errors.As(err, &tokenErr) && tokenErr.InvalidClient() {
+				lastErr = err
+				continue
+			}
+
+			return "", time.Now(), err
+		}
+
+		if r.onCredential != nil {
+			r.onCredential(i, c.ClientID)
+		}
+
+		return token, expiresAt, nil
+	}
+
+	return "", time.Now(), lastErr
+}
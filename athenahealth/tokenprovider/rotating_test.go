@@ -0,0 +1,153 @@
+package tokenprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRotatingTokenServer only accepts the secret "new".
+func testRotatingTokenServer(t *testing.T, attempts *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, secret, _ := r.BasicAuth()
+		*attempts = append(*attempts, secret)
+
+		switch secret {
+		case "new":
+			w.Write([]byte(`{"access_token": "foo", "expires_in": "3600"}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client", "error_description": "Client authentication failed"}`))
+		}
+	}))
+}
+
+func TestRotating_Provide(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts []string
+
+	ts := testRotatingTokenServer(t, &attempts)
+	defer ts.Close()
+
+	usedIndex := -1
+
+	p := NewRotating(ts.Client(), []Credential{
+		{ClientID: "client-id", Secret: "old"},
+		{ClientID: "client-id", Secret: "new"},
+	}, false).WithOnCredential(func(index int, clientID string) {
+		usedIndex = index
+		assert.Equal("client-id", clientID)
+	})
+	p.authURL = ts.URL
+
+	token, _, err := p.Provide(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+	assert.Equal(1, usedIndex)
+	assert.Equal([]string{"old", "new"}, attempts)
+}
+
+func TestRotating_Provide_allRejected(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts []string
+
+	ts := testRotatingTokenServer(t, &attempts)
+	defer ts.Close()
+
+	p := NewRotating(ts.Client(), []Credential{
+		{ClientID: "client-id", Secret: "old"},
+		{ClientID: "client-id", Secret: "older"},
+	}, false)
+	p.authURL = ts.URL
+
+	token, _, err := p.Provide(context.Background())
+	assert.Empty(token)
+	assert.Equal([]string{"old", "older"}, attempts)
+
+	var tokenErr *TokenError
+	assert.True(errors.As(err, &tokenErr))
+	assert.Equal(http.StatusUnauthorized, tokenErr.StatusCode)
+	assert.Equal("invalid_client", tokenErr.ErrorCode)
+	assert.Equal("Client authentication failed", tokenErr.Description)
+}
+
+func TestRotating_Provide_otherError(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts []string
+
+	ts := testRotatingTokenServer(t, &attempts)
+	defer ts.Close()
+
+	p := NewRotating(ts.Client(), []Credential{
+		{ClientID: "client-id", Secret: "broken"},
+		{ClientID: "client-id", Secret: "new"},
+	}, false)
+	p.authURL = ts.URL
+
+	_, _, err := p.Provide(context.Background())
+	assert.Error(err)
+
+	// Only rejected credentials fall back to the next one.
+	assert.Equal([]string{"broken"}, attempts)
+}
+
+func TestRotatingFunc_Provide(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts []string
+
+	ts := testRotatingTokenServer(t, &attempts)
+	defer ts.Close()
+
+	var credentials []Credential
+
+	p := NewRotatingFunc(ts.Client(), func(context.Context) ([]Credential, error) {
+		return credentials, nil
+	}, false)
+	p.authURL = ts.URL
+
+	_, _, err := p.Provide(context.Background())
+	assert.Equal(ErrNoCredentials, err)
+
+	credentials = []Credential{{ClientID: "client-id", Secret: "new"}}
+
+	token, _, err := p.Provide(context.Background())
+	assert.NoError(err)
+	assert.Equal("foo", token)
+
+	sourceErr := errors.New("source failed")
+	p.credentials = func(context.Context) ([]Credential, error) {
+		return nil, sourceErr
+	}
+
+	_, _, err = p.Provide(context.Background())
+	assert.Equal(sourceErr, err)
+}
+
+func TestNewRotating(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewRotating(&http.Client{}, nil, true)
+	assert.Equal(PreviewAuthURL, p.authURL)
+
+	p = NewRotating(&http.Client{}, nil, false)
+	assert.Equal(ProdAuthURL, p.authURL)
+
+	// Changing the slice afterwards doesn't change the credentials that are tried.
+	credentials := []Credential{{ClientID: "client-id", Secret: "old"}}
+	p = NewRotating(&http.Client{}, credentials, false)
+	credentials[0].Secret = "changed"
+
+	got, err := p.credentials(context.Background())
+	assert.NoError(err)
+	assert.Equal("old", got[0].Secret)
+}
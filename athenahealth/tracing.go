@@ -0,0 +1,90 @@
+package athenahealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/asatish/go-athenahealth/athenahealth/stats"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "github.com/asatish/go-athenahealth/athenahealth"
+
+// Span attribute keys.
+const (
+	attrHTTPMethod     = label.Key("http.method")
+	attrHTTPRoute      = label.Key("http.route")
+	attrHTTPStatusCode = label.Key("http.status_code")
+	attrPracticeID     = label.Key("athenahealth.practice_id")
+	attrAthenaError    = label.Key("athenahealth.error")
+	attrRetries        = label.Key("athenahealth.retries")
+	attrRateLimitWait  = label.Key("athenahealth.rate_limit_wait_ms")
+)
+
+// WithTracerProvider sets the OpenTelemetry tracer provider used to create a client span for every
+// API request and a child span for every token fetch. Spans only record the templated path
+// (e.g. "/patients/:id:"), never the full URL or query string, which can contain PHI.
+func (h *HTTPClient) WithTracerProvider(tp trace.TracerProvider) *HTTPClient {
+	h.tracer = tp.Tracer(tracerName)
+
+	return h
+}
+
+// startRequestSpan starts the client span for a request to path for practiceID.
+func (h *HTTPClient) startRequestSpan(ctx context.Context, method, path, practiceID string) (context.Context, trace.Span) {
+	route := stats.CleanPath(path)
+
+	return h.tracer.Start(ctx, "athenahealth "+method+" "+route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrHTTPMethod.String(method),
+			attrHTTPRoute.String(route),
+			attrPracticeID.String(practiceID),
+		),
+	)
+}
+
+// finishRequestSpan records the outcome of a request on its span.
+func finishRequestSpan(span trace.Span, res *http.Response, err error, info *requestInfo) {
+	span.SetAttributes(
+		attrRetries.Int(info.retries),
+		attrRateLimitWait.Int64(info.rateLimitWait.Milliseconds()),
+	)
+
+	if res != nil {
+		span.SetAttributes(attrHTTPStatusCode.Int(res.StatusCode))
+	}
+
+	if err == nil {
+		return
+	}
+
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) {
+		if len(apiErr.AthenaError) > 0 {
+			span.SetAttributes(attrAthenaError.String(apiErr.AthenaError))
+		}
+
+		if apiErr.HTTPResponse != nil {
+			span.SetAttributes(attrHTTPStatusCode.Int(apiErr.HTTPResponse.StatusCode))
+		}
+
+		// The detailed message can echo request values back, so only the status is recorded.
+		span.SetStatus(codes.Error, "athenahealth API error")
+		return
+	}
+
+	// url.Error includes the request URL and its query string.
+	urlErr := &url.Error{}
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	span.SetStatus(codes.Error, err.Error())
+}
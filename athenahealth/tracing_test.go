@@ -0,0 +1,152 @@
+package athenahealth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/asatish/go-athenahealth/athenahealth/tokencacher"
+)
+
+func testTracedClient(h http.HandlerFunc) (*HTTPClient, *oteltest.StandardSpanRecorder, func()) {
+	sr := new(oteltest.StandardSpanRecorder)
+
+	athenaClient, ts := testClient(h)
+	athenaClient.WithTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(sr)))
+
+	return athenaClient, sr, ts.Close
+}
+
+func TestHTTPClient_request_tracing(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`[{"patientid": "1"}]`))
+	}
+
+	athenaClient, sr, done := testTracedClient(h)
+	defer done()
+
+	athenaClient.WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          2,
+		BaseBackoff:          time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		RetryableMethods:     []string{http.MethodGet},
+	})
+
+	_, err := athenaClient.GetPatient(context.Background(), "1", nil)
+	assert.NoError(err)
+
+	spans := sr.Completed()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	attrs := span.Attributes()
+
+	assert.Equal("athenahealth GET /patients/:id:", span.Name())
+	assert.Equal(trace.SpanKindClient, span.SpanKind())
+	assert.Equal("GET", attrs["http.method"].AsString())
+	assert.Equal("/patients/:id:", attrs["http.route"].AsString())
+	assert.Equal(int64(200), attrs["http.status_code"].AsInt64())
+	assert.Equal(int64(1), attrs["athenahealth.retries"].AsInt64())
+	assert.Contains(attrs, label.Key("athenahealth.rate_limit_wait_ms"))
+	assert.Equal(codes.Unset, span.StatusCode())
+}
+
+func TestHTTPClient_request_tracing_error(t *testing.T) {
+	assert := assert.New(t)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "The patient is not available"}`))
+	}
+
+	athenaClient, sr, done := testTracedClient(h)
+	defer done()
+
+	_, err := athenaClient.GetPatient(context.Background(), "1", nil)
+	assert.Error(err)
+
+	spans := sr.Completed()
+	assert.Len(spans, 1)
+
+	attrs := spans[0].Attributes()
+
+	assert.Equal(int64(404), attrs["http.status_code"].AsInt64())
+	assert.Equal("The patient is not available", attrs["athenahealth.error"].AsString())
+	assert.Equal(codes.Error, spans[0].StatusCode())
+}
+
+func TestHTTPClient_request_tracing_token(t *testing.T) {
+	assert := assert.New(t)
+
+	athenaClient, sr, done := testTracedClient(nil)
+	defer done()
+
+	athenaClient.WithTokenCacher(tokencacher.NewDefault())
+
+	_, err := athenaClient.request(context.Background(), "GET", "/", nil, nil, nil)
+	assert.NoError(err)
+
+	spans := sr.Completed()
+	assert.Len(spans, 2)
+
+	var tokenSpan, requestSpan *oteltest.Span
+	for _, span := range spans {
+		if span.Name() == "athenahealth token" {
+			tokenSpan = span
+		} else {
+			requestSpan = span
+		}
+	}
+
+	assert.NotNil(tokenSpan)
+	assert.NotNil(requestSpan)
+	assert.Equal(requestSpan.SpanContext().SpanID, tokenSpan.ParentSpanID())
+	assert.Equal(requestSpan.SpanContext().TraceID, tokenSpan.SpanContext().TraceID)
+}
+
+func TestHTTPClient_request_tracing_noPHI(t *testing.T) {
+	assert := assert.New(t)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadFile("./resources/ListPatients.json")
+		w.Write(b)
+	}
+
+	athenaClient, sr, done := testTracedClient(h)
+	defer done()
+
+	_, err := athenaClient.ListPatients(context.Background(), &ListPatientsOptions{
+		FirstName: "Jonathan",
+		LastName:  "Smithers",
+	})
+	assert.NoError(err)
+
+	spans := sr.Completed()
+	assert.Len(spans, 1)
+	assert.NotContains(spans[0].Name(), "Jonathan")
+
+	for k, v := range spans[0].Attributes() {
+		assert.NotContains(v.Emit(), "Jonathan", k)
+		assert.NotContains(v.Emit(), "Smithers", k)
+		assert.False(strings.Contains(v.Emit(), "?"), k)
+	}
+}
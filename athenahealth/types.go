@@ -29,3 +29,31 @@ func (n *NumberString) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// BoolString is a bool that athenahealth sometimes encodes as the string "true" or "false".
+type BoolString bool
+
+func (b *BoolString) UnmarshalJSON(data []byte) error {
+	var aux interface{}
+
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	switch v := aux.(type) {
+	case bool:
+		*b = BoolString(v)
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+
+		*b = BoolString(parsed)
+	default:
+		return fmt.Errorf("unknown type: %T", v)
+	}
+
+	return nil
+}
@@ -51,3 +51,52 @@ func TestNumberString_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestBoolString_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    BoolString
+		wantErr bool
+	}{
+		{
+			name: "string true",
+			data: []byte(`"true"`),
+			want: true,
+		},
+		{
+			name: "string false",
+			data: []byte(`"false"`),
+			want: false,
+		},
+		{
+			name: "bool value",
+			data: []byte(`true`),
+			want: true,
+		},
+		{
+			name:    "invalid string value",
+			data:    []byte(`"yes please"`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid number value",
+			data:    []byte(`1`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b BoolString
+
+			err := b.UnmarshalJSON(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BoolString.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if b != tt.want {
+				t.Errorf("BoolString.UnmarshalJSON() = %v, want %v", b, tt.want)
+			}
+		})
+	}
+}
@@ -11,10 +11,15 @@ require (
 	github.com/go-redis/redis_rate/v9 v9.1.1
 	github.com/gomodule/redigo v1.8.4 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.9.0
 	github.com/rs/zerolog v1.23.0
 	github.com/stretchr/testify v1.7.0
 	github.com/yuin/gopher-lua v0.0.0-20200816102855-ee81675732da // indirect
+	go.opentelemetry.io/otel v0.17.0
+	go.opentelemetry.io/otel/oteltest v0.17.0
+	go.opentelemetry.io/otel/trace v0.17.0
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
 
 replace github.com/go-redis/redis/v8 => github.com/go-redis/redis/v8 v8.6.0